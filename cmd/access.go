@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"bot-map/interaction"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Ações sobre as localidades cujos cargos autorizados cada guilda configura em tempo de execução.
+const (
+	ActionCreateLocation = "criar"   // Criar localidades
+	ActionEditLocation   = "editar"  // Editar localidades existentes (descrição, tags)
+	ActionDeleteLocation = "remover" // Remover localidades
+)
+
+// Actions lista as ações configuráveis, na ordem exibida aos administradores.
+var Actions = []string{ActionCreateLocation, ActionEditLocation, ActionDeleteLocation}
+
+// Access declara quem pode usar um comando. Todos os critérios informados precisam ser atendidos;
+// os donos do bot sempre têm acesso.
+type Access struct {
+	Permissions int64    // Permissões do Discord exigidas (ex.: PermissionManageGuild); Administrator basta
+	Roles       []string // Cargos fixos; o membro precisa ter pelo menos um
+	Action      string   // Ação cujos cargos são configurados por guilda (ex.: ActionCreateLocation)
+	OwnersOnly  bool     // Apenas os donos do bot
+}
+
+// AccessDeniedError explica por que o usuário não pode executar o comando.
+type AccessDeniedError struct {
+	Reason string
+}
+
+func (e *AccessDeniedError) Error() string {
+	return "acesso negado: " + e.Reason
+}
+
+// RolePolicy guarda, por guilda, os cargos autorizados para cada ação.
+// AddRole e RemoveRole alteram um único cargo de forma atômica, para que dois administradores
+// mexendo ao mesmo tempo não percam a alteração um do outro.
+type RolePolicy interface {
+	Roles(guildID, action string) []string
+	SetRoles(guildID, action string, roles []string) error
+	AddRole(guildID, action, roleID string) (added bool, err error)            // false se o cargo já estava autorizado
+	RemoveRole(guildID, action, roleID string) (remaining []string, err error) // Cargos que continuam autorizados
+}
+
+// MemoryRolePolicy é uma RolePolicy em memória.
+type MemoryRolePolicy struct {
+	mu    sync.RWMutex
+	roles map[string]map[string][]string // guilda -> ação -> cargos
+}
+
+// Cria uma MemoryRolePolicy vazia: sem cargos configurados, as ações ficam liberadas.
+func NewMemoryRolePolicy() *MemoryRolePolicy {
+	return &MemoryRolePolicy{roles: make(map[string]map[string][]string)}
+}
+
+// Roles retorna uma cópia dos cargos autorizados para a ação na guilda.
+func (p *MemoryRolePolicy) Roles(guildID, action string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return append([]string(nil), p.roles[guildID][action]...)
+}
+
+// SetRoles substitui os cargos autorizados para a ação na guilda; uma lista vazia libera a ação.
+func (p *MemoryRolePolicy) SetRoles(guildID, action string, roles []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.setRoles(guildID, action, roles)
+	return nil
+}
+
+// AddRole autoriza o cargo para a ação na guilda.
+func (p *MemoryRolePolicy) AddRole(guildID, action, roleID string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	roles := p.roles[guildID][action]
+	if slices.Contains(roles, roleID) {
+		return false, nil
+	}
+	p.setRoles(guildID, action, append(slices.Clip(roles), roleID))
+	return true, nil
+}
+
+// RemoveRole retira a autorização do cargo para a ação na guilda.
+func (p *MemoryRolePolicy) RemoveRole(guildID, action, roleID string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	remaining := slices.DeleteFunc(slices.Clone(p.roles[guildID][action]), func(existing string) bool {
+		return existing == roleID
+	})
+	p.setRoles(guildID, action, remaining)
+	return remaining, nil
+}
+
+// Substitui os cargos da ação; precisa ser chamada com p.mu.
+func (p *MemoryRolePolicy) setRoles(guildID, action string, roles []string) {
+	if p.roles[guildID] == nil {
+		p.roles[guildID] = make(map[string][]string)
+	}
+
+	if len(roles) == 0 {
+		delete(p.roles[guildID], action)
+		return
+	}
+
+	p.roles[guildID][action] = append([]string(nil), roles...)
+}
+
+// AccessControl verifica as exigências de acesso dos comandos.
+type AccessControl struct {
+	Owners map[string]bool // IDs dos donos do bot
+	Policy RolePolicy      // Cargos configurados por guilda para cada ação
+}
+
+// Cria um AccessControl com os donos e a política de cargos informados.
+func NewAccessControl(owners []string, policy RolePolicy) *AccessControl {
+	ac := &AccessControl{Owners: make(map[string]bool), Policy: policy}
+	for _, owner := range owners {
+		ac.Owners[owner] = true
+	}
+	return ac
+}
+
+// IsOwner indica se quem invocou a interação é dono do bot.
+func (ac *AccessControl) IsOwner(i *interaction.Interaction) bool {
+	user := i.Invoker()
+	return user != nil && ac.Owners[user.ID]
+}
+
+// Check verifica se quem invocou a interação atende às exigências.
+// Na DM não há cargos nem permissões de guilda: exigências desse tipo negam o acesso,
+// enquanto os cargos configurados por ação não se aplicam.
+func (ac *AccessControl) Check(i *interaction.Interaction, access Access) error {
+	if ac.IsOwner(i) {
+		return nil
+	}
+
+	if access.OwnersOnly {
+		return &AccessDeniedError{Reason: "apenas os donos do bot podem usar este comando"}
+	}
+
+	member := i.Member
+	if member == nil {
+		if access.Permissions != 0 || len(access.Roles) > 0 {
+			return &AccessDeniedError{Reason: "este comando só pode ser usado em um servidor"}
+		}
+		return nil
+	}
+
+	bits := member.PermissionBits()
+	admin := bits&PermissionAdministrator != 0
+
+	if access.Permissions != 0 && !admin && bits&access.Permissions != access.Permissions {
+		return &AccessDeniedError{Reason: "você não tem as permissões necessárias"}
+	}
+
+	if len(access.Roles) > 0 && !admin && !hasAnyRole(member, access.Roles) {
+		return &AccessDeniedError{Reason: "você não tem um dos cargos necessários"}
+	}
+
+	if access.Action != "" && !admin {
+		roles := ac.Policy.Roles(i.GuildID, access.Action)
+		if len(roles) > 0 && !hasAnyRole(member, roles) {
+			return &AccessDeniedError{Reason: fmt.Sprintf("você não tem um cargo autorizado a %s localidades", access.Action)}
+		}
+	}
+
+	return nil
+}
+
+// Middleware verifica as exigências declaradas no comando e na folha antes de executá-los,
+// respondendo de forma efêmera quando o acesso é negado. Autocompletes não são verificados.
+func (ac *AccessControl) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*interaction.Response, error) {
+			if req.Autocomplete() {
+				return next(ctx, req)
+			}
+
+			for _, access := range req.Route.access {
+				if err := ac.Check(req.Interaction, access); err != nil {
+					return interaction.EphemeralMessage("🔒 " + err.Error()), nil
+				}
+			}
+
+			return next(ctx, req)
+		}
+	}
+}
+
+// Verifica se o membro tem pelo menos um dos cargos.
+func hasAnyRole(member *interaction.Member, roles []string) bool {
+	for _, role := range roles {
+		if member.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,105 +1,241 @@
 package cmd
 
 import (
-	"bot-map/config"
-	"bot-map/shared"
-	"bytes"
-	"encoding/json"
+	"bot-map/interaction"
+	"bot-map/store"
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
 // Estrutura que adc localidade
 type AddLocalCommand struct {
-	Config      *config.Config    // Configurações do bot (como a URL base e o token)
-	Client      shared.HTTPClient // Cliente HTTP para fazer requisições
-	Localidades map[string]string // Mapa para armazenar localidades e suas descrições
+	Localidades store.LocationStore // Armazenamento das localidades
+	Access      *AccessControl      // Verifica a permissão de edição ao sobrescrever uma localidade
 }
 
 // Função que cria e retorna um novo comando de adicionar localidade
-func NewAddLocalCommand(config *config.Config, client shared.HTTPClient, localidades map[string]string) *CommandInfo {
-	// Instancia a estrutura do comando com as configurações e o cliente HTTP
+func NewAddLocalCommand(localidades store.LocationStore, access *AccessControl) *CommandInfo {
+	// Instancia a estrutura do comando com o armazenamento de localidades
 	addLocalCmd := &AddLocalCommand{
-		Config:      config,
-		Client:      client,
 		Localidades: localidades,
+		Access:      access,
 	}
 
 	// Retorna as informações do comando para o Discord, incluindo nome, descrição e opções
 	return &CommandInfo{
 		Name:        "addlocal",
 		Description: "Adiciona uma nova localidade.",
-		Options: []discordgo.ApplicationCommandOption{
-			{
-				Name:        "nome",
-				Description: "Nome da localidade",
-				Type:        discordgo.ApplicationCommandOptionString,
-				Required:    true,
-			},
-			{
-				Name:        "descricao",
-				Description: "Descrição da localidade",
-				Type:        discordgo.ApplicationCommandOptionString,
-				Required:    true,
-			},
+		Options:     addLocalOptions(),
+		Command:     addLocalCmd,
+		Cooldowns:   addLocalCooldowns(),
+		Access:      &Access{Action: ActionCreateLocation},
+		Scope:       Global(),
+		Contexts: []InteractionContext{
+			ContextGuild,
+			ContextBotDM,
 		},
-		Command: addLocalCmd,
+	}
+}
+
+// Opções de /addlocal, também usadas pelo subcomando /local adicionar
+func addLocalOptions() []discordgo.ApplicationCommandOption {
+	return []discordgo.ApplicationCommandOption{
+		{
+			Name:        "nome",
+			Description: "Nome da localidade",
+			Type:        discordgo.ApplicationCommandOptionString,
+			Required:    true,
+		},
+		{
+			Name:        "descricao",
+			Description: "Descrição da localidade",
+			Type:        discordgo.ApplicationCommandOptionString,
+			Required:    true,
+		},
+		{
+			Name:        "categoria",
+			Description: "Categoria da localidade (ex.: cidade, masmorra)",
+			Type:        discordgo.ApplicationCommandOptionString,
+		},
+		{
+			Name:        "tags",
+			Description: "Tags separadas por vírgula",
+			Type:        discordgo.ApplicationCommandOptionString,
+		},
+		{
+			Name:        "latitude",
+			Description: "Latitude (informe junto com a longitude)",
+			Type:        discordgo.ApplicationCommandOptionNumber,
+			MinValue:    &minLatitude,
+			MaxValue:    90,
+		},
+		{
+			Name:        "longitude",
+			Description: "Longitude (informe junto com a latitude)",
+			Type:        discordgo.ApplicationCommandOptionNumber,
+			MinValue:    &minLongitude,
+			MaxValue:    180,
+		},
+		{
+			Name:        "imagem",
+			Description: "URL de uma imagem da localidade",
+			Type:        discordgo.ApplicationCommandOptionString,
+		},
+		{
+			Name:        "link",
+			Description: "Link externo com mais informações",
+			Type:        discordgo.ApplicationCommandOptionString,
+		},
+		versaoOption("Versão exibida em /local listar; necessária para sobrescrever uma localidade existente"),
+	}
+}
+
+// Limites mínimos das coordenadas (MinValue exige um ponteiro)
+var (
+	minLatitude  = -90.0
+	minLongitude = -180.0
+)
+
+// Limites de uso de /addlocal e /local adicionar, que escrevem nas localidades compartilhadas.
+// Os dois comandos dividem o mesmo limite, então alternar entre eles não dobra as gravações.
+func addLocalCooldowns() []Cooldown {
+	return []Cooldown{
+		{Scope: CooldownUser, Uses: 3, Window: time.Minute, Bucket: "adicionar local"},
+		{Scope: CooldownGuild, Uses: 20, Window: time.Minute, Bucket: "adicionar local"},
 	}
 }
 
 // Método que executa o comando quando chamado pelo usuário
-func (c *AddLocalCommand) Execute(interaction map[string]interface{}) error {
-	// Obtém as opções fornecidas pelo usuário na interação do Discord
-	options := interaction["data"].(map[string]interface{})["options"].([]interface{})
+func (c *AddLocalCommand) Execute(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error) {
+	// Extrai os valores das opções da interação (nome e descrição)
+	nome, okNome := opts.String("nome")
+	descricao, okDescricao := opts.String("descricao")
 
 	// Verifica se foram passados os dois argumentos necessários (nome e descrição)
-	if len(options) < 2 {
-		return fmt.Errorf("faltam argumentos! Use: /addlocal <nome> <descrição>")
+	if !okNome || !okDescricao {
+		return nil, fmt.Errorf("faltam argumentos! Use: /local adicionar <nome> <descrição>")
 	}
 
-	// Extrai os valores das opções da interação (nome e descrição)
-	nome := options[0].(map[string]interface{})["value"].(string)
-	descricao := options[1].(map[string]interface{})["value"].(string)
+	// Sobrescrever uma localidade existente é uma edição e exige o cargo correspondente
+	local, err := c.Localidades.Get(namespaceFor(i), nome)
+	existe := err == nil
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, err
+	}
+
+	if existe && c.Access != nil {
+		var denied *AccessDeniedError
+		if err := c.Access.Check(i, Access{Action: ActionEditLocation}); errors.As(err, &denied) {
+			return interaction.EphemeralMessage(fmt.Sprintf("🔒 A localidade **%s** já existe e %s.", nome, denied.Reason)), nil
+		}
+	}
+
+	// Sobrescrever exige a versão que o usuário viu em /local listar. O armazenamento compara com ela,
+	// e não com a leitura acima, para que uma alteração feita por outra pessoa depois disso não se perca.
+	versao, informada := opts.Int("versao")
+	switch {
+	case informada:
+		local.Version = versao
+	case existe:
+		return interaction.EphemeralMessage(fmt.Sprintf(
+			"⚠️ A localidade **%s** já existe (versão %d). Confira com `/local listar` e informe `versao` para sobrescrevê-la.",
+			nome, local.Version,
+		)), nil
+	}
+
+	// Adiciona a localidade; numa localidade existente, os campos opcionais não informados são mantidos
+	local.Name = nome
+	local.Description = descricao
+	local.UpdatedBy = invokerID(i)
+	if err := applyOptionalFields(&local, opts); err != nil {
+		return nil, err
+	}
+	salvo, err := c.Localidades.Put(namespaceFor(i), local)
+	if err != nil {
+		if response, ok := conflictResponse(err); ok {
+			return response, nil
+		}
+		return nil, err
+	}
 
-	// Adiciona a localidade
-	c.Localidades[nome] = descricao
-	fmt.Println("Localidade adicionada:", nome)
+	if existe {
+		return interaction.Message(fmt.Sprintf("🗺️ Localidade **%s** atualizada (versão %d)!\nDescrição: ***%s***", nome, salvo.Version, descricao)), nil
+	}
 
 	// Cria a resposta para ser enviada ao Discord
-	response := map[string]interface{}{
-		"type": 4, // Tipo 4 significa resposta de interação imediata (CHANNEL_MESSAGE_WITH_SOURCE) (Discordgo )
-		"data": map[string]interface{}{
-			"content": fmt.Sprintf("🗺️ Localidade **%s** adicionada!\nDescrição: ***%s***", nome, descricao),
-		},
+	return interaction.Message(fmt.Sprintf("🗺️ Localidade **%s** adicionada!\nDescrição: ***%s***", nome, descricao)), nil
+}
+
+// Aplica as opções opcionais (categoria, tags, coordenadas, imagem e link) à localidade
+func applyOptionalFields(local *store.Location, opts *Options) error {
+	if categoria, ok := opts.String("categoria"); ok {
+		local.Category = strings.TrimSpace(categoria)
+	}
+
+	if tags, ok := opts.String("tags"); ok {
+		local.Tags = parseTags(tags)
 	}
 
-	// Converte a resposta para JSON
-	jsonResponse, _ := json.Marshal(response)
+	latitude, okLatitude := opts.Number("latitude")
+	longitude, okLongitude := opts.Number("longitude")
+	switch {
+	case okLatitude && okLongitude:
+		if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+			return fmt.Errorf("coordenadas fora do intervalo válido")
+		}
+		local.Coordinates = &store.Coordinates{Latitude: latitude, Longitude: longitude}
+	case okLatitude || okLongitude:
+		return fmt.Errorf("informe a latitude e a longitude juntas")
+	}
+
+	if imagem, ok := opts.String("imagem"); ok {
+		if err := validateURL(imagem); err != nil {
+			return fmt.Errorf("imagem inválida: %w", err)
+		}
+		local.ImageURL = imagem
+	}
 
-	// Constrói a URL da API do Discord para responder à interação
-	interactionID := interaction["id"].(string)
-	interactionToken := interaction["token"].(string)
-	url := fmt.Sprintf("%s/interactions/%s/%s/callback", c.Config.BaseURL, interactionID, interactionToken)
+	if link, ok := opts.String("link"); ok {
+		if err := validateURL(link); err != nil {
+			return fmt.Errorf("link inválido: %w", err)
+		}
+		local.Link = link
+	}
 
-	// Cria uma requisição HTTP para enviar a resposta ao Discord
-	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonResponse))
-	req.Header.Set("Authorization", "Bot "+c.Config.Token) // Adiciona a autenticação do bot
-	req.Header.Set("Content-Type", "application/json")     // Define o tipo de conteúdo como JSON
+	return nil
+}
 
-	// Envia a requisição HTTP
-	resp, err := c.Client.Do(req)
+// Separa as tags informadas por vírgula, em minúsculas e sem repetições
+func parseTags(value string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// Aceita apenas URLs http(s) absolutas
+func validateURL(value string) error {
+	parsed, err := url.Parse(value)
 	if err != nil {
-		fmt.Println("Erro ao enviar resposta:", err)
 		return err
 	}
-	defer resp.Body.Close() // Garante que o corpo da resposta será fechado após a execução
-
-	// Verifica se a resposta foi bem-sucedida
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("falha ao enviar mensagem, código: %d", resp.StatusCode)
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("use uma URL http ou https")
 	}
-
-	return nil // Retorna nil indicando que não houve erro
+	return nil
 }
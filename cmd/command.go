@@ -1,11 +1,32 @@
 package cmd
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"bot-map/interaction"
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+)
 
 // Command é uma interface que define a estrutura de um comando do bot.
 type Command interface {
 	// Execute é o método que será implementado por cada comando, processando a interação recebida.
-	Execute(interaction map[string]interface{}) error
+	// As opções chegam já validadas contra CommandInfo.Options e acessíveis pelo nome.
+	// A resposta devolvida é entregue ao Discord pelo responder, então o comando não faz HTTP.
+	// Comandos demorados podem usar interaction.FollowupsFrom(ctx) para editar a resposta depois.
+	Execute(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error)
+}
+
+// CommandFunc permite usar uma função comum como Command.
+type CommandFunc func(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error)
+
+// Execute chama a própria função.
+func (f CommandFunc) Execute(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error) {
+	return f(ctx, i, opts)
+}
+
+// AutocompleteCommand é implementada pelos comandos que sugerem valores enquanto o usuário digita.
+type AutocompleteCommand interface {
+	HandleAutocomplete(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error)
 }
 
 // CommandInfo armazena informações sobre um comando registrado no bot.
@@ -14,4 +35,15 @@ type CommandInfo struct {
 	Description string                               // Descrição do comando
 	Options     []discordgo.ApplicationCommandOption // Opções disponíveis para o comando (parâmetros)
 	Command     Command                              // Instância do comando que implementa a interface Command
+
+	Subcommands []*SubcommandInfo  // Subcomandos; quando presentes, Options e Command são ignorados
+	Groups      []*SubcommandGroup // Grupos de subcomandos
+	Middleware  []Middleware       // Middlewares do comando, aplicados depois dos globais
+	Cooldowns   []Cooldown         // Limites de uso compartilhados por todos os subcomandos
+	Access      *Access            // Quem pode usar o comando (nil = todos)
+
+	Scope                    CommandScope         // Onde o comando é registrado (padrão: guilda de desenvolvimento)
+	DefaultMemberPermissions *int64               // Permissões exigidas por padrão para usar o comando (nil = todos)
+	Contexts                 []InteractionContext // Onde o comando global pode ser usado (nil = padrão do Discord)
+	IntegrationTypes         []IntegrationType    // Instalações em que o comando global aparece (nil = padrão do Discord)
 }
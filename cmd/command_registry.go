@@ -2,7 +2,8 @@ package cmd
 
 // CommandRegistry é uma estrutura que gerencia o registro de comandos do bot.
 type CommandRegistry struct {
-	commands map[string]*CommandInfo // Mapa que armazena os comandos pelo nome
+	commands   map[string]*CommandInfo // Mapa que armazena os comandos pelo nome
+	middleware []Middleware            // Middlewares aplicados a todos os comandos
 }
 
 // NewCommandRegistry cria e retorna uma nova instância de CommandRegistry.
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"bot-map/interaction"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CooldownScope indica a quem um cooldown se aplica.
+type CooldownScope int
+
+const (
+	CooldownUser    CooldownScope = iota // Cada usuário tem o próprio limite
+	CooldownChannel                      // O limite é compartilhado pelo canal
+	CooldownGuild                        // O limite é compartilhado pela guilda (na DM, pelo canal)
+)
+
+// Cooldown limita um comando a Uses execuções a cada Window, por escopo.
+// Um cooldown com Uses ou Window menor ou igual a zero não limita nada.
+type Cooldown struct {
+	Scope  CooldownScope // A quem o limite se aplica
+	Uses   int           // Execuções permitidas na janela
+	Window time.Duration // Tamanho da janela
+	Bucket string        // Nome do limite compartilhado entre rotas; vazio usa o nome da própria rota
+}
+
+// CooldownLimit é um limite já associado à chave de quem está usando o comando.
+type CooldownLimit struct {
+	Key    string        // Ex.: "local adicionar:user:123"
+	Uses   int           // Execuções permitidas na janela
+	Window time.Duration // Tamanho da janela
+}
+
+// CooldownStore guarda os usos dos comandos. A implementação em memória pode ser trocada
+// por uma persistente sem mudar os comandos.
+type CooldownStore interface {
+	// Take registra um uso em todos os limites se nenhum deles estiver esgotado; caso contrário
+	// não registra nada e devolve quanto falta para o limite mais restritivo liberar.
+	Take(now time.Time, limits ...CooldownLimit) (retryAfter time.Duration, ok bool)
+}
+
+// Cooldown declarado em um nível da rota, com o nome usado na chave.
+type routeCooldown struct {
+	name     string
+	cooldown Cooldown
+}
+
+// Cooldowns aplica os cooldowns declarados no comando e na folha, respondendo de forma efêmera
+// com o tempo restante quando o limite está esgotado. Autocompletes não contam.
+func Cooldowns(store CooldownStore) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*interaction.Response, error) {
+			if req.Autocomplete() || len(req.Route.cooldowns) == 0 {
+				return next(ctx, req)
+			}
+
+			limits := make([]CooldownLimit, 0, len(req.Route.cooldowns))
+			for _, declared := range req.Route.cooldowns {
+				if !declared.cooldown.limited() {
+					continue
+				}
+				limits = append(limits, CooldownLimit{
+					Key:    cooldownKey(declared, req.Interaction),
+					Uses:   declared.cooldown.Uses,
+					Window: declared.cooldown.Window,
+				})
+			}
+
+			if len(limits) == 0 {
+				return next(ctx, req)
+			}
+
+			if retryAfter, ok := store.Take(time.Now(), limits...); !ok {
+				return interaction.EphemeralMessage(fmt.Sprintf(
+					"⏳ Calma! Você poderá usar `/%s` de novo em %s.", req.Route.Name(), formatRemaining(retryAfter),
+				)), nil
+			}
+
+			return next(ctx, req)
+		}
+	}
+}
+
+// Indica se o cooldown realmente limita o comando.
+func (c Cooldown) limited() bool {
+	return c.Uses > 0 && c.Window > 0
+}
+
+// Monta a chave do cooldown a partir do escopo e da interação.
+func cooldownKey(declared routeCooldown, i *interaction.Interaction) string {
+	name := declared.name
+	if declared.cooldown.Bucket != "" {
+		name = declared.cooldown.Bucket
+	}
+
+	switch declared.cooldown.Scope {
+	case CooldownChannel:
+		return fmt.Sprintf("%s:channel:%s", name, i.ChannelID)
+	case CooldownGuild:
+		if i.GuildID == "" {
+			return fmt.Sprintf("%s:channel:%s", name, i.ChannelID)
+		}
+		return fmt.Sprintf("%s:guild:%s", name, i.GuildID)
+	default:
+		return fmt.Sprintf("%s:user:%s", name, invokerID(i))
+	}
+}
+
+// Formata o tempo restante arredondado para cima em segundos.
+func formatRemaining(d time.Duration) string {
+	return max(d.Truncate(time.Second)+time.Second, time.Second).String()
+}
+
+// Quantidade de chamadas a Take entre limpezas das chaves expiradas.
+const cooldownSweepEvery = 1024
+
+// MemoryCooldownStore guarda os usos em memória com janela deslizante.
+type MemoryCooldownStore struct {
+	mu      sync.Mutex
+	entries map[string]*cooldownEntry // Usos recentes de cada chave
+	takes   int                       // Chamadas desde a última limpeza
+}
+
+// Usos de uma chave dentro da janela.
+type cooldownEntry struct {
+	uses   []time.Time   // Momentos dos usos, do mais antigo ao mais recente
+	window time.Duration // Janela do limite da chave
+}
+
+// Cria um MemoryCooldownStore vazio.
+func NewMemoryCooldownStore() *MemoryCooldownStore {
+	return &MemoryCooldownStore{entries: make(map[string]*cooldownEntry)}
+}
+
+// Take implementa CooldownStore.
+func (s *MemoryCooldownStore) Take(now time.Time, limits ...CooldownLimit) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.takes++
+	if s.takes >= cooldownSweepEvery {
+		s.sweep(now)
+		s.takes = 0
+	}
+
+	// Primeiro confere todos os limites, para não consumir um uso se outro limite bloquear
+	// Limites sem usos ou sem janela não limitam nada (e indexariam os usos fora do intervalo)
+	valid := limits[:0:0]
+	for _, limit := range limits {
+		if limit.Uses > 0 && limit.Window > 0 {
+			valid = append(valid, limit)
+		}
+	}
+	limits = valid
+
+	var retryAfter time.Duration
+	for _, limit := range limits {
+		entry := s.entry(limit)
+		entry.prune(now)
+
+		if len(entry.uses) >= limit.Uses {
+			// O uso que precisa sair da janela para liberar uma vaga
+			retryAfter = max(retryAfter, entry.uses[len(entry.uses)-limit.Uses].Add(limit.Window).Sub(now))
+		}
+	}
+
+	if retryAfter > 0 {
+		return retryAfter, false
+	}
+
+	for _, limit := range limits {
+		entry := s.entries[limit.Key]
+		entry.uses = append(entry.uses, now)
+	}
+
+	return 0, true
+}
+
+// Retorna a entrada da chave, criando-a se necessário.
+func (s *MemoryCooldownStore) entry(limit CooldownLimit) *cooldownEntry {
+	entry, ok := s.entries[limit.Key]
+	if !ok {
+		entry = &cooldownEntry{}
+		s.entries[limit.Key] = entry
+	}
+	entry.window = limit.Window
+	return entry
+}
+
+// Remove as chaves sem usos dentro da janela, para a memória não crescer com usuários inativos.
+func (s *MemoryCooldownStore) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if entry.prune(now); len(entry.uses) == 0 {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// Descarta os usos que já saíram da janela.
+func (e *cooldownEntry) prune(now time.Time) {
+	idx := 0
+	for idx < len(e.uses) && !e.uses[idx].After(now.Add(-e.window)) {
+		idx++
+	}
+	e.uses = e.uses[idx:]
+}
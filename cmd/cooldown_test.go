@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bot-map/interaction"
+	"testing"
+	"time"
+)
+
+func TestMemoryCooldownStoreTake(t *testing.T) {
+	store := NewMemoryCooldownStore()
+	now := time.Unix(1_700_000_000, 0)
+	limit := CooldownLimit{Key: "local adicionar:user:1", Uses: 2, Window: time.Minute}
+
+	for use := 0; use < 2; use++ {
+		if _, ok := store.Take(now, limit); !ok {
+			t.Fatalf("uso %d bloqueado dentro do limite", use+1)
+		}
+	}
+
+	retryAfter, ok := store.Take(now.Add(10*time.Second), limit)
+	if ok {
+		t.Fatal("terceiro uso liberado com o limite esgotado")
+	}
+	if retryAfter != 50*time.Second {
+		t.Errorf("retryAfter = %s, esperado 50s", retryAfter)
+	}
+
+	if _, ok := store.Take(now.Add(time.Minute+time.Second), limit); !ok {
+		t.Error("uso bloqueado depois de a janela passar")
+	}
+}
+
+func TestMemoryCooldownStoreIgnoresInvalidLimits(t *testing.T) {
+	store := NewMemoryCooldownStore()
+	now := time.Unix(1_700_000_000, 0)
+
+	limits := []CooldownLimit{
+		{Key: "a:user:1", Uses: 0, Window: time.Minute},
+		{Key: "b:user:1", Uses: -1, Window: time.Minute},
+		{Key: "c:user:1", Uses: 1, Window: 0},
+	}
+
+	for use := 0; use < 3; use++ {
+		if _, ok := store.Take(now, limits...); !ok {
+			t.Fatalf("uso %d bloqueado por um limite inválido", use+1)
+		}
+	}
+}
+
+func TestAddLocalRoutesShareCooldown(t *testing.T) {
+	registry, _ := newLocalTestRegistry(NewMemoryRolePolicy())
+	registry.Use(Cooldowns(NewMemoryCooldownStore()))
+
+	addlocal := func(nome string) *interaction.Interaction {
+		return guildCommand("addlocal",
+			optionValue("nome", interaction.OptionString, nome),
+			optionValue("descricao", interaction.OptionString, "Descrição"),
+		)
+	}
+
+	// O limite por usuário é de 3 gravações por minuto, somando os dois comandos
+	mustRun(t, registry, adicionar("Vila", "Descrição"))
+	mustRun(t, registry, addlocal("Porto"))
+	mustRun(t, registry, adicionar("Mercado", "Descrição"))
+	assertEphemeral(t, mustRun(t, registry, addlocal("Farol")), "⏳")
+}
@@ -1,198 +1,372 @@
 package cmd
 
 import (
-	"bot-map/config"
-	"bot-map/shared"
-	"bytes"
-	"encoding/json"
+	"bot-map/interaction"
+	"bot-map/store"
+	"context"
+	"errors"
 	"fmt"
-	"log"
-	"net/http"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
-// Estrutura que representa o comando para listar e buscar localidades
+// Estrutura que representa o subcomando /local listar, que lista e busca localidades
 type LocalCommand struct {
-	Config      *config.Config    // Configurações do bot (como a URL base e o token)
-	Client      shared.HTTPClient // Cliente HTTP para fazer requisições
-	Localidades map[string]string // Mapa que armazena localidades e suas descrições
+	Localidades store.LocationStore // Armazenamento das localidades
 }
 
-// Função que cria e retorna um novo comando para listar/buscar localidades
-func NewLocalCommand(config *config.Config, client shared.HTTPClient, localidades map[string]string) *CommandInfo {
-	// Instancia a estrutura do comando com as configurações e o cliente HTTP
-	localCmd := &LocalCommand{
-		Config:      config,
-		Client:      client,
-		Localidades: localidades,
-	}
+// Estrutura que representa o subcomando /local remover
+type RemoveLocalCommand struct {
+	Localidades store.LocationStore
+}
+
+// Estrutura que representa o subcomando /local tag adicionar
+type AddTagCommand struct {
+	Localidades store.LocationStore
+}
+
+// Função que cria e retorna o comando /local com os subcomandos listar, adicionar, remover e tag adicionar
+func NewLocalCommand(localidades store.LocationStore, access *AccessControl) *CommandInfo {
+	// Os subcomandos compartilham o armazenamento de localidades
+	localCmd := &LocalCommand{Localidades: localidades}
+	removeCmd := &RemoveLocalCommand{Localidades: localidades}
+	addTagCmd := &AddTagCommand{Localidades: localidades}
 
-	// Retorna as informações do comando para o Discord, incluindo nome, descrição e opções
+	// Retorna as informações do comando para o Discord; a árvore de opções é gerada a partir dos subcomandos
 	return &CommandInfo{
 		Name:        "local",
-		Description: "Mostra uma lista de locais disponíveis ou detalhes de um local específico.",
-		Options: []discordgo.ApplicationCommandOption{
+		Description: "Consulta e gerencia as localidades.",
+		Subcommands: []*SubcommandInfo{
 			{
-				Name:         "nome",
-				Description:  "Nome do local para obter detalhes",
-				Type:         discordgo.ApplicationCommandOptionString,
-				Required:     false,
-				Autocomplete: true, // Habilita sugestões ao digitar o nome do local
+				Name:        "listar",
+				Description: "Mostra uma lista de locais disponíveis ou detalhes de um local específico.",
+				Options: []discordgo.ApplicationCommandOption{
+					nomeOption("Nome do local para obter detalhes", false),
+				},
+				Command: localCmd,
+			},
+			{
+				Name:        "adicionar",
+				Description: "Adiciona uma nova localidade.",
+				Options:     addLocalOptions(),
+				Command:     &AddLocalCommand{Localidades: localidades, Access: access},
+				Cooldowns:   addLocalCooldowns(),
+				Access:      &Access{Action: ActionCreateLocation},
+			},
+			{
+				Name:        "remover",
+				Description: "Remove uma localidade.",
+				Options: []discordgo.ApplicationCommandOption{
+					nomeOption("Nome do local a remover", true),
+					versaoOption("Versão exibida em /local listar; sem ela a localidade é removida em qualquer versão"),
+				},
+				Command: removeCmd,
+				Access:  &Access{Action: ActionDeleteLocation},
+				Cooldowns: []Cooldown{
+					{Scope: CooldownUser, Uses: 3, Window: time.Minute},
+				},
 			},
 		},
-		Command: localCmd,
+		Groups: []*SubcommandGroup{
+			{
+				Name:        "tag",
+				Description: "Gerencia as tags das localidades.",
+				Subcommands: []*SubcommandInfo{
+					{
+						Name:        "adicionar",
+						Description: "Adiciona uma tag a uma localidade.",
+						Options: []discordgo.ApplicationCommandOption{
+							nomeOption("Nome do local", true),
+							{
+								Name:        "tag",
+								Description: "Tag a adicionar",
+								Type:        discordgo.ApplicationCommandOptionString,
+								Required:    true,
+							},
+							versaoOption("Versão exibida em /local listar; sem ela a tag é somada à versão atual"),
+						},
+						Command: addTagCmd,
+						Access:  &Access{Action: ActionEditLocation},
+						Cooldowns: []Cooldown{
+							{Scope: CooldownUser, Uses: 10, Window: time.Minute},
+						},
+					},
+				},
+			},
+		},
+		Scope: Global(), // Disponível em todas as guildas e também na DM com o bot
+		Contexts: []InteractionContext{
+			ContextGuild,
+			ContextBotDM,
+			ContextPrivateChannel,
+		},
+		IntegrationTypes: []IntegrationType{
+			IntegrationGuildInstall,
+			IntegrationUserInstall,
+		},
 	}
 }
 
-// Método que executa o comando quando chamado pelo usuário
-func (c *LocalCommand) Execute(interaction map[string]interface{}) error {
-	// Obtém as opções fornecidas pelo usuário na interação do Discord
-	options, ok := interaction["data"].(map[string]interface{})["options"].([]interface{})
+// Opção "nome" com autocomplete dos locais cadastrados
+func nomeOption(description string, required bool) discordgo.ApplicationCommandOption {
+	return discordgo.ApplicationCommandOption{
+		Name:         "nome",
+		Description:  description,
+		Type:         discordgo.ApplicationCommandOptionString,
+		Required:     required,
+		Autocomplete: true, // Habilita sugestões ao digitar o nome do local
+	}
+}
+
+// Opção "versao": a versão da localidade que o usuário viu, para que uma alteração feita
+// por outra pessoa depois disso não seja sobrescrita
+func versaoOption(description string) discordgo.ApplicationCommandOption {
+	return discordgo.ApplicationCommandOption{
+		Name:        "versao",
+		Description: description,
+		Type:        discordgo.ApplicationCommandOptionInteger,
+		MinValue:    &minVersao,
+	}
+}
+
+// Menor versão de uma localidade gravada (MinValue exige um ponteiro)
+var minVersao = 1.0
 
-	// Exibe no console as localidades armazenadas no momento (apenas para depuração)
-	fmt.Println("Locais armazenados no momento:", c.Localidades)
+// Método que executa o comando quando chamado pelo usuário
+func (c *LocalCommand) Execute(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error) {
+	// Obtém o nome do local, se o usuário informou um
+	nome, informado := opts.String("nome")
 
 	var responseText string // Variável que armazenará a resposta do bot
 
 	// Se o usuário não especificou um local, lista todas as localidades disponíveis
-	if !ok || len(options) == 0 {
-		if len(c.Localidades) == 0 {
-			responseText = "Nenhuma localidade cadastrada ainda! Use `/addlocal` para adicionar uma."
+	if !informado {
+		locais, err := c.Localidades.List(namespaceFor(i))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(locais) == 0 {
+			responseText = "Nenhuma localidade cadastrada ainda! Use `/local adicionar` para adicionar uma."
 		} else {
 			responseText = "**Locais disponíveis:**\n"
-			for nome := range c.Localidades {
-				responseText += fmt.Sprintf("- %s\n", nome)
+			for _, local := range locais {
+				if local.Category != "" {
+					responseText += fmt.Sprintf("- %s *(%s)*\n", local.Name, local.Category)
+				} else {
+					responseText += fmt.Sprintf("- %s\n", local.Name)
+				}
 			}
 		}
 	} else {
-		// Se o usuário forneceu um nome, busca a descrição correspondente
-		nome := options[0].(map[string]interface{})["value"].(string)
-		descricao, existe := c.Localidades[nome]
-
-		// Se a localidade existe, exibe suas informações; caso contrário, informa que não foi encontrada
-		if existe {
-			responseText = fmt.Sprintf("️🧭 **%s**\n\n- ***%s***", nome, descricao)
-		} else {
+		// Se o usuário forneceu um nome, busca a localidade correspondente
+		local, err := c.Localidades.Get(namespaceFor(i), nome)
+
+		// Se a localidade existe, exibe seus detalhes; caso contrário, informa que não foi encontrada
+		switch {
+		case err == nil:
+			return interaction.EmbedMessage(locationEmbed(local)), nil
+		case errors.Is(err, store.ErrNotFound):
 			responseText = fmt.Sprintf("❌ Localidade '%s' não encontrada.", nome)
+		default:
+			return nil, err
 		}
 	}
 
 	// Monta a resposta que será enviada ao Discord
-	response := map[string]interface{}{
-		"type": 4, // Tipo 4 significa resposta de interação imediata (Discordgo)
-		"data": map[string]interface{}{
-			"content": responseText,
-		},
+	return interaction.Message(responseText), nil
+}
+
+// Monta o embed com os detalhes da localidade; campos não preenchidos ficam de fora
+func locationEmbed(local store.Location) interaction.Embed {
+	embed := interaction.Embed{
+		Title:       "🧭 " + local.Name,
+		Description: local.Description,
+		URL:         local.Link,
+		Footer:      &interaction.EmbedFooter{Text: fmt.Sprintf("ID %s · versão %d", local.ID, local.Version)},
 	}
 
-	// Converte a resposta para JSON
-	jsonResponse, err := json.Marshal(response)
-	if err != nil {
-		log.Println("Erro ao serializar resposta:", err)
-		return err
+	if local.Category != "" {
+		embed.Fields = append(embed.Fields, interaction.EmbedField{Name: "Categoria", Value: local.Category, Inline: true})
 	}
 
-	// Obtém o ID e o token da interação para construir a URL de resposta
-	interactionID := interaction["id"].(string)
-	interactionToken := interaction["token"].(string)
+	if len(local.Tags) > 0 {
+		embed.Fields = append(embed.Fields, interaction.EmbedField{Name: "Tags", Value: "🏷️ " + strings.Join(local.Tags, ", "), Inline: true})
+	}
 
-	url := fmt.Sprintf("%s/interactions/%s/%s/callback", c.Config.BaseURL, interactionID, interactionToken)
+	if coords := local.Coordinates; coords != nil {
+		embed.Fields = append(embed.Fields, interaction.EmbedField{
+			Name: "Coordenadas",
+			Value: fmt.Sprintf("[%.5f, %.5f](https://www.openstreetmap.org/?mlat=%f&mlon=%f)",
+				coords.Latitude, coords.Longitude, coords.Latitude, coords.Longitude),
+			Inline: true,
+		})
+	}
 
-	// Cria uma requisição HTTP para enviar a resposta ao Discord
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonResponse))
-	if err != nil {
-		log.Println("Erro ao criar requisição:", err)
-		return err
+	if local.Link != "" {
+		embed.Fields = append(embed.Fields, interaction.EmbedField{Name: "Link", Value: local.Link})
 	}
 
-	req.Header.Set("Authorization", "Bot "+c.Config.Token) // Adiciona a autenticação do bot
-	req.Header.Set("Content-Type", "application/json")     // Define o tipo de conteúdo como JSON
+	if local.AuthorID != "" {
+		embed.Fields = append(embed.Fields, interaction.EmbedField{Name: "Autor", Value: fmt.Sprintf("<@%s>", local.AuthorID), Inline: true})
+	}
 
-	// Envia a requisição HTTP
-	resp, err := c.Client.Do(req)
-	if err != nil {
-		log.Println("Erro ao enviar resposta:", err)
-		return err
+	if !local.CreatedAt.IsZero() {
+		embed.Fields = append(embed.Fields, interaction.EmbedField{Name: "Criada", Value: fmt.Sprintf("<t:%d:R>", local.CreatedAt.Unix()), Inline: true})
+	}
+
+	if !local.UpdatedAt.IsZero() {
+		embed.Fields = append(embed.Fields, interaction.EmbedField{Name: "Atualizada", Value: fmt.Sprintf("<t:%d:R>", local.UpdatedAt.Unix()), Inline: true})
+		embed.Timestamp = local.UpdatedAt.Format(time.RFC3339)
 	}
-	defer resp.Body.Close() // Fecha o corpo da resposta para liberar recursos
 
-	// Verifica se a resposta foi bem-sucedida
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("Falha ao enviar mensagem, código: %d", resp.StatusCode)
+	if local.ImageURL != "" {
+		embed.Image = &interaction.EmbedImage{URL: local.ImageURL}
 	}
 
-	return nil // Retorna nil indicando que não houve erro
+	return embed
 }
 
 // Método que trata o autocomplete de nomes de localidades no Discord
-func (c *LocalCommand) HandleAutocomplete(interaction map[string]interface{}) error {
-	// Obtém o ID e o token da interação para construir a URL de resposta
-	interactionID := interaction["id"].(string)
-	interactionToken := interaction["token"].(string)
+func (c *LocalCommand) HandleAutocomplete(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error) {
+	return autocompleteLocalidades(c.Localidades, i, opts)
+}
 
-	// Obtém os dados da interação, incluindo as opções digitadas pelo usuário
-	data := interaction["data"].(map[string]interface{})
-	options := data["options"].([]interface{})
+// Remove a localidade e suas tags
+func (c *RemoveLocalCommand) Execute(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error) {
+	nome, _ := opts.String("nome")
 
-	// Obtém a opção que está sendo preenchida e o valor digitado pelo usuário
-	focusedOption := options[0].(map[string]interface{})
-	inputValue := focusedOption["value"].(string)
-
-	var suggestions []map[string]interface{} // Lista de sugestões a serem enviadas ao usuário
-
-	// Filtra as localidades para sugerir apenas aquelas que começam com o que foi digitado
-	for nome := range c.Localidades {
-		if strings.HasPrefix(strings.ToLower(nome), strings.ToLower(inputValue)) {
-			suggestions = append(suggestions, map[string]interface{}{
-				"name":  nome,
-				"value": nome,
-			})
+	// Com a versão informada, a remoção falha se a localidade mudou depois que o usuário a viu
+	versao, informada := opts.Int("versao")
+	if !informada {
+		versao = store.AnyVersion
+	}
+
+	if err := c.Localidades.Delete(namespaceFor(i), nome, versao); errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("localidade '%s' não encontrada", nome)
+	} else if err != nil {
+		if response, ok := conflictResponse(err); ok {
+			return response, nil
 		}
+		return nil, err
 	}
 
-	// Monta a resposta do autocomplete
-	response := map[string]interface{}{
-		"type": 8, // Tipo 8 significa resposta de autocomplete (Discordgo)
-		"data": map[string]interface{}{
-			"choices": suggestions,
-		},
+	return interaction.Message(fmt.Sprintf("🗑️ Localidade **%s** removida.", nome)), nil
+}
+
+// Sugere os locais cadastrados para remoção
+func (c *RemoveLocalCommand) HandleAutocomplete(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error) {
+	return autocompleteLocalidades(c.Localidades, i, opts)
+}
+
+// Adiciona uma tag à localidade, ignorando tags repetidas
+func (c *AddTagCommand) Execute(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error) {
+	nome, _ := opts.String("nome")
+	tag, _ := opts.String("tag")
+
+	local, err := c.Localidades.Get(namespaceFor(i), nome)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("localidade '%s' não encontrada", nome)
+	} else if err != nil {
+		return nil, err
 	}
 
-	// Converte a resposta para JSON
-	jsonResponse, err := json.Marshal(response)
-	if err != nil {
-		log.Println("Local não encontrado no autocomplete:", err)
-		return err
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return nil, fmt.Errorf("a tag não pode ser vazia")
 	}
 
-	// Cria a URL da API do Discord para responder ao autocomplete
-	url := fmt.Sprintf("%s/interactions/%s/%s/callback", c.Config.BaseURL, interactionID, interactionToken)
+	for _, existente := range local.Tags {
+		if existente == tag {
+			return interaction.EphemeralMessage(fmt.Sprintf("🏷️ **%s** já tem a tag `%s`.", nome, tag)), nil
+		}
+	}
 
-	// Cria uma requisição HTTP para enviar as sugestões ao Discord
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonResponse))
-	if err != nil {
-		log.Println("Erro ao criar requisição HTTP:", err)
-		return err
+	// Somar uma tag não desfaz alterações de outras pessoas, então sem a versão informada vale a atual;
+	// com ela, a tag só é gravada se a localidade não mudou desde que o usuário a viu
+	if versao, informada := opts.Int("versao"); informada {
+		local.Version = versao
 	}
 
-	req.Header.Set("Authorization", "Bot "+c.Config.Token) // Adiciona a autenticação do bot
-	req.Header.Set("Content-Type", "application/json")     // Define o tipo de conteúdo como JSON
+	local.Tags = append(local.Tags, tag)
+	local.UpdatedBy = invokerID(i)
+	if _, err := c.Localidades.Put(namespaceFor(i), local); err != nil {
+		if response, ok := conflictResponse(err); ok {
+			return response, nil
+		}
+		return nil, err
+	}
+
+	return interaction.Message(fmt.Sprintf("🏷️ Tag `%s` adicionada a **%s**.", tag, nome)), nil
+}
+
+// Sugere os locais cadastrados ao escolher onde adicionar a tag
+func (c *AddTagCommand) HandleAutocomplete(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error) {
+	return autocompleteLocalidades(c.Localidades, i, opts)
+}
 
-	// Envia a requisição HTTP
-	resp, err := c.Client.Do(req)
+// Sugere as localidades cujo nome contém o texto digitado na opção "nome"
+func autocompleteLocalidades(localidades store.LocationStore, i *interaction.Interaction, opts *Options) (*interaction.Response, error) {
+	// Obtém a opção que está sendo preenchida e o valor digitado pelo usuário
+	focused, inputValue, ok := opts.Focused()
+	if !ok || focused != "nome" {
+		return nil, fmt.Errorf("autocomplete sem opção focada")
+	}
+
+	// Busca as localidades, com as que começam pelo que foi digitado primeiro
+	locais, err := localidades.Search(namespaceFor(i), inputValue, interaction.MaxChoices)
 	if err != nil {
-		log.Println("Erro ao enviar resposta de autocomplete:", err)
-		return err
+		return nil, err
+	}
+
+	var suggestions []interaction.Choice // Lista de sugestões a serem enviadas ao usuário
+	for _, local := range locais {
+		suggestions = append(suggestions, interaction.Choice{
+			Name:  local.Name,
+			Value: local.Name,
+		})
 	}
-	defer resp.Body.Close() // Fecha o corpo da resposta para liberar recursos
 
-	// Verifica se a resposta foi bem-sucedida
-	if resp.StatusCode != http.StatusOK {
-		log.Println("Erro na resposta de autocomplete:", resp.StatusCode)
-		return fmt.Errorf("erro na resposta de autocomplete: %d", resp.StatusCode)
+	// Monta a resposta do autocomplete
+	return interaction.Autocomplete(suggestions...), nil
+}
+
+// Responde a um conflito de versão: outra pessoa alterou ou removeu a localidade enquanto ela era editada
+func conflictResponse(err error) (*interaction.Response, bool) {
+	var conflict *store.ConflictError
+	if !errors.As(err, &conflict) {
+		return nil, false
 	}
 
-	return nil // Retorna nil indicando que não houve erro
+	if conflict.Current == nil {
+		return interaction.EphemeralMessage(fmt.Sprintf("⚠️ A localidade **%s** foi removida enquanto você a editava.", conflict.Name)), true
+	}
+
+	autor := "outra pessoa"
+	if conflict.Current.UpdatedBy != "" {
+		autor = fmt.Sprintf("<@%s>", conflict.Current.UpdatedBy)
+	}
+
+	return interaction.EphemeralMessage(fmt.Sprintf(
+		"⚠️ A localidade **%s** foi modificada por %s (agora na versão %d). Confira com `/local listar` e tente de novo.",
+		conflict.Name, autor, conflict.Current.Version,
+	)), true
+}
+
+// Retorna o namespace das localidades da interação: a guilda ou, nas DMs, o próprio usuário
+func namespaceFor(i *interaction.Interaction) store.Namespace {
+	if i.GuildID != "" {
+		return store.GuildNamespace(i.GuildID)
+	}
+	return store.UserNamespace(invokerID(i))
+}
+
+// Retorna o ID de quem invocou a interação
+func invokerID(i *interaction.Interaction) string {
+	if user := i.Invoker(); user != nil {
+		return user.ID
+	}
+	return ""
 }
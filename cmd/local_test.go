@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"bot-map/interaction"
+	"bot-map/store"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Registro com /local e /addlocal sobre um MemoryStore, com o controle de acesso aplicado.
+func newLocalTestRegistry(policy RolePolicy) (*CommandRegistry, *store.MemoryStore) {
+	localidades := store.NewMemoryStore()
+	access := NewAccessControl(nil, policy)
+
+	registry := NewCommandRegistry()
+	registry.Use(access.Middleware())
+	registry.RegistryCommand(NewLocalCommand(localidades, access))
+	registry.RegistryCommand(NewAddLocalCommand(localidades, access))
+
+	return registry, localidades
+}
+
+// Opção preenchida pelo usuário.
+func optionValue(name string, optionType interaction.OptionType, value interface{}) *interaction.OptionValue {
+	encoded, _ := json.Marshal(value)
+	return &interaction.OptionValue{Name: name, Type: optionType, Value: encoded}
+}
+
+// Subcomando (ou grupo) com as opções informadas.
+func subcommandValue(name string, options ...*interaction.OptionValue) *interaction.OptionValue {
+	return &interaction.OptionValue{Name: name, Type: interaction.OptionSubCommand, Options: options}
+}
+
+// Interação de um membro na guilda 1, sem permissões especiais.
+func guildCommand(command string, options ...*interaction.OptionValue) *interaction.Interaction {
+	return &interaction.Interaction{
+		ID:        "100",
+		Type:      interaction.TypeApplicationCommand,
+		GuildID:   "1",
+		ChannelID: "2",
+		Token:     "token",
+		Member:    &interaction.Member{User: &interaction.User{ID: "10"}, Permissions: "0"},
+		Data:      &interaction.ApplicationCommandData{Name: command, Options: options},
+	}
+}
+
+// Interação de um usuário na DM com o bot.
+func dmCommand(command string, options ...*interaction.OptionValue) *interaction.Interaction {
+	i := guildCommand(command, options...)
+	i.GuildID = ""
+	i.Member = nil
+	i.User = &interaction.User{ID: "10"}
+	return i
+}
+
+// Roteia e executa a interação como o cliente do Discord faz.
+func runCommand(t *testing.T, ctx context.Context, registry *CommandRegistry, i *interaction.Interaction) (*interaction.Response, error) {
+	t.Helper()
+
+	route, err := registry.Resolve(i)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	opts, err := route.Options(i)
+	if err != nil {
+		t.Fatalf("Options: %v", err)
+	}
+	return registry.Handler(route)(ctx, &Request{Interaction: i, Route: route, Options: opts})
+}
+
+// Executa a interação e falha o teste se o comando devolver erro.
+func mustRun(t *testing.T, registry *CommandRegistry, i *interaction.Interaction) *interaction.Response {
+	t.Helper()
+
+	response, err := runCommand(t, context.Background(), registry, i)
+	if err != nil {
+		t.Fatalf("comando %s: %v", i.CommandName(), err)
+	}
+	return response
+}
+
+func adicionar(nome, descricao string, extra ...*interaction.OptionValue) *interaction.Interaction {
+	options := append([]*interaction.OptionValue{
+		optionValue("nome", interaction.OptionString, nome),
+		optionValue("descricao", interaction.OptionString, descricao),
+	}, extra...)
+	return guildCommand("local", subcommandValue("adicionar", options...))
+}
+
+func versao(v int64) *interaction.OptionValue {
+	return optionValue("versao", interaction.OptionInteger, v)
+}
+
+func assertEphemeral(t *testing.T, response *interaction.Response, contains string) {
+	t.Helper()
+
+	if response == nil || response.Data == nil || response.Data.Flags&interaction.FlagEphemeral == 0 {
+		t.Fatalf("resposta = %+v, esperada mensagem efêmera", response)
+	}
+	if !strings.Contains(response.Data.Content, contains) {
+		t.Errorf("resposta %q não contém %q", response.Data.Content, contains)
+	}
+}
+
+func TestLocalAddAndList(t *testing.T) {
+	registry, localidades := newLocalTestRegistry(NewMemoryRolePolicy())
+
+	response := mustRun(t, registry, adicionar("Vila", "Vila inicial",
+		optionValue("categoria", interaction.OptionString, "cidade"),
+		optionValue("tags", interaction.OptionString, "Inicio, seguro, inicio"),
+	))
+	if !strings.Contains(response.Data.Content, "adicionada") {
+		t.Errorf("resposta da criação = %q", response.Data.Content)
+	}
+
+	local, err := localidades.Get(store.GuildNamespace("1"), "Vila")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if local.Version != 1 || local.AuthorID != "10" || local.Category != "cidade" || strings.Join(local.Tags, ",") != "inicio,seguro" {
+		t.Errorf("localidade gravada = %+v", local)
+	}
+
+	// Listagem geral e detalhes da localidade
+	list := mustRun(t, registry, guildCommand("local", subcommandValue("listar")))
+	if !strings.Contains(list.Data.Content, "- Vila *(cidade)*") {
+		t.Errorf("listagem = %q", list.Data.Content)
+	}
+
+	details := mustRun(t, registry, guildCommand("local", subcommandValue("listar", optionValue("nome", interaction.OptionString, "Vila"))))
+	if len(details.Data.Embeds) != 1 {
+		t.Fatalf("detalhes sem embed: %+v", details.Data)
+	}
+	if embed := details.Data.Embeds[0]; embed.Description != "Vila inicial" || !strings.Contains(embed.Footer.Text, "versão 1") {
+		t.Errorf("embed = %+v", embed)
+	}
+
+	// As localidades da guilda não aparecem na DM
+	dm := mustRun(t, registry, dmCommand("local", subcommandValue("listar")))
+	if !strings.Contains(dm.Data.Content, "Nenhuma localidade") {
+		t.Errorf("listagem na DM = %q", dm.Data.Content)
+	}
+}
+
+func TestLocalAddOverwriteNeedsTheVersionTheUserSaw(t *testing.T) {
+	registry, localidades := newLocalTestRegistry(NewMemoryRolePolicy())
+	ns := store.GuildNamespace("1")
+
+	mustRun(t, registry, adicionar("Vila", "Vila inicial"))
+
+	// Sem a versão, a localidade existente não é sobrescrita
+	assertEphemeral(t, mustRun(t, registry, adicionar("Vila", "Sobrescrita")), "versão 1")
+	if local, _ := localidades.Get(ns, "Vila"); local.Description != "Vila inicial" {
+		t.Fatalf("localidade sobrescrita sem versão: %+v", local)
+	}
+
+	// Com a versão vista, a edição vale
+	response := mustRun(t, registry, adicionar("Vila", "Vila reformada", versao(1)))
+	if !strings.Contains(response.Data.Content, "versão 2") {
+		t.Errorf("resposta da edição = %q", response.Data.Content)
+	}
+
+	// Outra edição baseada na mesma versão 1 é recusada, mesmo que o comando leia a versão atual
+	assertEphemeral(t, mustRun(t, registry, adicionar("Vila", "Edição atrasada", versao(1))), "versão 2")
+	if local, _ := localidades.Get(ns, "Vila"); local.Description != "Vila reformada" || local.Version != 2 {
+		t.Errorf("localidade depois do conflito = %+v", local)
+	}
+}
+
+func TestLocalTagAndRemove(t *testing.T) {
+	registry, localidades := newLocalTestRegistry(NewMemoryRolePolicy())
+	ns := store.GuildNamespace("1")
+
+	mustRun(t, registry, adicionar("Vila", "Vila inicial"))
+
+	tag := func(value string, extra ...*interaction.OptionValue) *interaction.Interaction {
+		options := append([]*interaction.OptionValue{
+			optionValue("nome", interaction.OptionString, "Vila"),
+			optionValue("tag", interaction.OptionString, value),
+		}, extra...)
+		return guildCommand("local", &interaction.OptionValue{
+			Name:    "tag",
+			Type:    interaction.OptionSubCommandGroup,
+			Options: []*interaction.OptionValue{subcommandValue("adicionar", options...)},
+		})
+	}
+
+	mustRun(t, registry, tag(" Porto "))
+	assertEphemeral(t, mustRun(t, registry, tag("porto")), "já tem a tag")
+	assertEphemeral(t, mustRun(t, registry, tag("mercado", versao(1))), "versão 2")
+
+	if local, _ := localidades.Get(ns, "Vila"); strings.Join(local.Tags, ",") != "porto" || local.Version != 2 {
+		t.Errorf("localidade depois das tags = %+v", local)
+	}
+
+	remover := func(extra ...*interaction.OptionValue) *interaction.Interaction {
+		options := append([]*interaction.OptionValue{optionValue("nome", interaction.OptionString, "Vila")}, extra...)
+		return guildCommand("local", subcommandValue("remover", options...))
+	}
+
+	assertEphemeral(t, mustRun(t, registry, remover(versao(1))), "versão 2")
+	mustRun(t, registry, remover(versao(2)))
+
+	if _, err := localidades.Get(ns, "Vila"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get depois da remoção: erro = %v, esperado ErrNotFound", err)
+	}
+	if _, err := runCommand(t, context.Background(), registry, remover()); err == nil {
+		t.Error("remoção de localidade inexistente não devolveu erro")
+	}
+}
+
+func TestLocalAutocomplete(t *testing.T) {
+	registry, _ := newLocalTestRegistry(NewMemoryRolePolicy())
+
+	for _, nome := range []string{"Vila", "Porto", "Vilarejo"} {
+		mustRun(t, registry, adicionar(nome, "Descrição"))
+	}
+
+	focused := optionValue("nome", interaction.OptionString, "vil")
+	focused.Focused = true
+	i := guildCommand("local", subcommandValue("listar", focused))
+	i.Type = interaction.TypeAutocomplete
+
+	response := mustRun(t, registry, i)
+	var names []string
+	for _, choice := range response.Data.Choices {
+		names = append(names, choice.Name)
+	}
+	if strings.Join(names, ",") != "Vila,Vilarejo" {
+		t.Errorf("sugestões = %v, esperado [Vila Vilarejo]", names)
+	}
+}
+
+func TestLocalAddRespectsConfiguredRoles(t *testing.T) {
+	policy := NewMemoryRolePolicy()
+	if err := policy.SetRoles("1", ActionCreateLocation, []string{"50"}); err != nil {
+		t.Fatal(err)
+	}
+	registry, localidades := newLocalTestRegistry(policy)
+
+	assertEphemeral(t, mustRun(t, registry, adicionar("Vila", "Vila inicial")), "🔒")
+	if _, err := localidades.Get(store.GuildNamespace("1"), "Vila"); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("localidade criada sem o cargo autorizado: %v", err)
+	}
+
+	i := adicionar("Vila", "Vila inicial")
+	i.Member.Roles = []string{"50"}
+	mustRun(t, registry, i)
+	if _, err := localidades.Get(store.GuildNamespace("1"), "Vila"); err != nil {
+		t.Errorf("localidade não criada com o cargo autorizado: %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bot-map/interaction"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Request é a interação já roteada que passa pela cadeia de middlewares.
+type Request struct {
+	Interaction *interaction.Interaction // Interação recebida
+	Route       *Route                   // Folha que trata a interação
+	Options     *Options                 // Opções validadas da folha
+}
+
+// Autocomplete indica se a requisição é um autocomplete em vez da execução do comando.
+func (r *Request) Autocomplete() bool {
+	return r.Interaction.Type == interaction.TypeAutocomplete
+}
+
+// Handler trata uma requisição e devolve a resposta para o Discord.
+type Handler func(ctx context.Context, req *Request) (*interaction.Response, error)
+
+// Middleware envolve um Handler para executar algo antes e depois dele.
+type Middleware func(next Handler) Handler
+
+// Erro devolvido ao usuário quando um comando entra em pânico.
+var ErrCommandPanic = errors.New("ocorreu um erro interno ao executar o comando, tente novamente")
+
+// Erro devolvido quando um comando passa do tempo limite.
+var ErrCommandTimeout = errors.New("o comando demorou demais para responder")
+
+// Use adiciona middlewares aplicados a todos os comandos. O primeiro informado é o mais externo.
+func (cr *CommandRegistry) Use(middleware ...Middleware) {
+	cr.middleware = append(cr.middleware, middleware...)
+}
+
+// Handler monta a cadeia da rota: middlewares globais, depois os do comando e por fim os da folha,
+// terminando na execução (ou no autocomplete) do comando.
+func (cr *CommandRegistry) Handler(route *Route) Handler {
+	handler := Handler(execute)
+
+	chain := make([]Middleware, 0, len(cr.middleware)+len(route.middleware))
+	chain = append(chain, cr.middleware...)
+	chain = append(chain, route.middleware...)
+
+	for idx := len(chain) - 1; idx >= 0; idx-- {
+		handler = chain[idx](handler)
+	}
+
+	return handler
+}
+
+// Último elo da cadeia: chama o comando da folha.
+func execute(ctx context.Context, req *Request) (*interaction.Response, error) {
+	if req.Autocomplete() {
+		autoCmd, ok := req.Route.Command.(AutocompleteCommand)
+		if !ok {
+			return nil, fmt.Errorf("comando %s não tem autocomplete", req.Route.Name())
+		}
+		return autoCmd.HandleAutocomplete(ctx, req.Interaction, req.Options)
+	}
+
+	return req.Route.Command.Execute(ctx, req.Interaction, req.Options)
+}
+
+// Recover transforma um pânico do comando em um erro amigável para o usuário,
+// registrando a pilha no log em vez de derrubar o bot.
+func Recover(logger *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (response *interaction.Response, err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					logger.Error("command panicked",
+						"command", req.Route.Name(),
+						"panic", fmt.Sprint(recovered),
+						"stack", string(debug.Stack()),
+					)
+					response, err = nil, ErrCommandPanic
+				}
+			}()
+
+			return next(ctx, req)
+		}
+	}
+}
+
+// Logging registra cada execução com o comando, o usuário, a guilda, a latência e o erro, se houver.
+func Logging(logger *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*interaction.Response, error) {
+			started := time.Now()
+			response, err := next(ctx, req)
+
+			attrs := []any{
+				"command", req.Route.Name(),
+				"interaction", req.Interaction.ID,
+				"guild", req.Interaction.GuildID,
+				"latency", time.Since(started),
+				"autocomplete", req.Autocomplete(),
+			}
+			if user := req.Interaction.Invoker(); user != nil {
+				attrs = append(attrs, "user", user.ID)
+			}
+
+			if err != nil {
+				logger.Warn("command failed", append(attrs, "error", err)...)
+			} else {
+				logger.Info("command handled", attrs...)
+			}
+
+			return response, err
+		}
+	}
+}
+
+// Timeout limita o tempo de execução do comando: o contexto recebido pelo comando é cancelado no prazo.
+// Um comando não pode ser interrompido à força, então Timeout espera ele retornar e entrega o resultado
+// real: se o comando desistiu por causa do prazo, o usuário recebe ErrCommandTimeout; se ele terminou
+// o que fazia (ex.: a gravação já tinha começado), o sucesso é reportado normalmente.
+// A resposta ao Discord dentro do prazo de 3 segundos fica a cargo do adiamento automático do cliente.
+func Timeout(d time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*interaction.Response, error) {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			response, err := next(ctx, req)
+			if err != nil && errors.Is(err, context.DeadlineExceeded) && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%w (%s)", ErrCommandTimeout, d)
+			}
+			return response, err
+		}
+	}
+}
+
+// MetricsRecorder recebe uma observação por execução de comando.
+type MetricsRecorder interface {
+	ObserveCommand(command string, latency time.Duration, err error)
+}
+
+// Metrics envia ao recorder a latência e o resultado de cada execução (autocomplete não conta).
+func Metrics(recorder MetricsRecorder) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*interaction.Response, error) {
+			if req.Autocomplete() {
+				return next(ctx, req)
+			}
+
+			started := time.Now()
+			response, err := next(ctx, req)
+			recorder.ObserveCommand(req.Route.Name(), time.Since(started), err)
+
+			return response, err
+		}
+	}
+}
+
+// CommandStats são os números acumulados de um comando.
+type CommandStats struct {
+	Command      string        // Caminho do comando (ex.: "local adicionar")
+	Calls        int           // Execuções
+	Errors       int           // Execuções que terminaram em erro
+	TotalLatency time.Duration // Soma das latências
+	MaxLatency   time.Duration // Maior latência observada
+}
+
+// AverageLatency retorna a latência média das execuções.
+func (s CommandStats) AverageLatency() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Calls)
+}
+
+// CommandMetrics é um MetricsRecorder em memória.
+type CommandMetrics struct {
+	mu    sync.Mutex
+	stats map[string]*CommandStats
+}
+
+// Cria um CommandMetrics vazio.
+func NewCommandMetrics() *CommandMetrics {
+	return &CommandMetrics{stats: make(map[string]*CommandStats)}
+}
+
+// ObserveCommand acumula uma execução.
+func (m *CommandMetrics) ObserveCommand(command string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.stats[command]
+	if !ok {
+		stats = &CommandStats{Command: command}
+		m.stats[command] = stats
+	}
+
+	stats.Calls++
+	if err != nil {
+		stats.Errors++
+	}
+	stats.TotalLatency += latency
+	stats.MaxLatency = max(stats.MaxLatency, latency)
+}
+
+// Snapshot retorna uma cópia dos números de todos os comandos, em ordem de nome.
+func (m *CommandMetrics) Snapshot() []CommandStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make([]CommandStats, 0, len(m.stats))
+	for _, stats := range m.stats {
+		snapshot = append(snapshot, *stats)
+	}
+	sort.Slice(snapshot, func(a, b int) bool { return snapshot[a].Command < snapshot[b].Command })
+
+	return snapshot
+}
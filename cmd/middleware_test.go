@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bot-map/interaction"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errNotAllowed = errors.New("não permitido")
+
+func TestTimeoutReportsWhatTheCommandDid(t *testing.T) {
+	const limit = 10 * time.Millisecond
+
+	tests := []struct {
+		name    string
+		handler Handler
+		wantErr error
+	}{
+		{
+			// O comando desistiu ao ver o prazo vencido
+			name: "desistiu",
+			handler: func(ctx context.Context, req *Request) (*interaction.Response, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			wantErr: ErrCommandTimeout,
+		},
+		{
+			// A gravação já tinha começado e terminou depois do prazo: o usuário recebe o sucesso
+			name: "terminou depois do prazo",
+			handler: func(ctx context.Context, req *Request) (*interaction.Response, error) {
+				<-ctx.Done()
+				return interaction.Message("✅ gravado"), nil
+			},
+		},
+		{
+			name: "erro do comando",
+			handler: func(ctx context.Context, req *Request) (*interaction.Response, error) {
+				return nil, errNotAllowed
+			},
+			wantErr: errNotAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := Timeout(limit)(test.handler)(context.Background(), &Request{})
+
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("erro = %v, esperado %v", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil || response == nil || response.Data.Content != "✅ gravado" {
+				t.Errorf("resultado = %+v, %v; esperado a resposta do comando", response, err)
+			}
+		})
+	}
+}
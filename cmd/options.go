@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"bot-map/interaction"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Options dá acesso às opções de uma interação pelo nome declarado em CommandInfo.Options,
+// independentemente da ordem em que o Discord as envia.
+type Options struct {
+	values   map[string]*interaction.OptionValue // Opções enviadas, pelo nome
+	resolved *interaction.ResolvedData           // Objetos referenciados pelas opções (usuários, cargos...)
+	focused  *interaction.OptionValue            // Opção sendo preenchida no autocomplete
+}
+
+// NewOptions valida as opções recebidas contra as declaradas no comando e monta o acesso por nome.
+// Opções desconhecidas, de tipo diferente do declarado ou obrigatórias ausentes geram erro.
+// No autocomplete as opções obrigatórias ainda podem estar vazias e o valor focado é texto parcial.
+func NewOptions(declared []discordgo.ApplicationCommandOption, i *interaction.Interaction) (*Options, error) {
+	if i.Data == nil {
+		return newOptions(declared, nil, i)
+	}
+	return newOptions(declared, i.Data.Options, i)
+}
+
+// Valida as opções enviadas para um nível da árvore (comando ou subcomando).
+func newOptions(declared []discordgo.ApplicationCommandOption, values []*interaction.OptionValue, i *interaction.Interaction) (*Options, error) {
+	opts := &Options{values: make(map[string]*interaction.OptionValue)}
+
+	if i.Data == nil {
+		return opts, nil
+	}
+	opts.resolved = i.Data.Resolved
+
+	// Indexa as opções declaradas pelo nome
+	declaredByName := make(map[string]discordgo.ApplicationCommandOption, len(declared))
+	for _, option := range declared {
+		declaredByName[option.Name] = option
+	}
+
+	autocomplete := i.Type == interaction.TypeAutocomplete
+
+	for _, value := range values {
+		option, ok := declaredByName[value.Name]
+		if !ok {
+			return nil, fmt.Errorf("opção desconhecida: %s", value.Name)
+		}
+
+		if int(value.Type) != int(option.Type) {
+			return nil, fmt.Errorf("opção %s com tipo %d, esperado %d", value.Name, value.Type, option.Type)
+		}
+
+		if value.Focused {
+			opts.focused = value
+		} else if err := validateOptionValue(value); err != nil {
+			return nil, err
+		}
+
+		opts.values[value.Name] = value
+	}
+
+	// Confere se todas as opções obrigatórias foram enviadas
+	if !autocomplete {
+		for _, option := range declared {
+			if _, ok := opts.values[option.Name]; option.Required && !ok {
+				return nil, fmt.Errorf("opção obrigatória ausente: %s", option.Name)
+			}
+		}
+	}
+
+	return opts, nil
+}
+
+// Verifica se o valor enviado é compatível com o tipo da opção.
+func validateOptionValue(value *interaction.OptionValue) error {
+	var err error
+
+	switch value.Type {
+	case interaction.OptionString, interaction.OptionUser, interaction.OptionChannel,
+		interaction.OptionRole, interaction.OptionMentionable, interaction.OptionAttachment:
+		var s string
+		err = json.Unmarshal(value.Value, &s)
+	case interaction.OptionInteger:
+		var n float64
+		if err = json.Unmarshal(value.Value, &n); err == nil && n != math.Trunc(n) {
+			err = fmt.Errorf("%v não é inteiro", n)
+		}
+	case interaction.OptionNumber:
+		var n float64
+		err = json.Unmarshal(value.Value, &n)
+	case interaction.OptionBoolean:
+		var b bool
+		err = json.Unmarshal(value.Value, &b)
+	}
+
+	if err != nil {
+		return fmt.Errorf("valor inválido para a opção %s: %w", value.Name, err)
+	}
+	return nil
+}
+
+// Has indica se a opção foi enviada.
+func (o *Options) Has(name string) bool {
+	_, ok := o.values[name]
+	return ok
+}
+
+// String retorna o valor de uma opção de texto.
+func (o *Options) String(name string) (string, bool) {
+	var s string
+	return s, o.decode(name, &s, interaction.OptionString)
+}
+
+// Int retorna o valor de uma opção inteira.
+func (o *Options) Int(name string) (int64, bool) {
+	var n float64
+	ok := o.decode(name, &n, interaction.OptionInteger)
+	return int64(n), ok
+}
+
+// Number retorna o valor de uma opção numérica.
+func (o *Options) Number(name string) (float64, bool) {
+	var n float64
+	return n, o.decode(name, &n, interaction.OptionNumber)
+}
+
+// Bool retorna o valor de uma opção booleana.
+func (o *Options) Bool(name string) (bool, bool) {
+	var b bool
+	return b, o.decode(name, &b, interaction.OptionBoolean)
+}
+
+// User retorna o usuário escolhido em uma opção de usuário.
+func (o *Options) User(name string) (*interaction.User, bool) {
+	id, ok := o.snowflake(name, interaction.OptionUser)
+	if !ok || o.resolved == nil {
+		return nil, false
+	}
+	user, ok := o.resolved.Users[id]
+	return user, ok
+}
+
+// Channel retorna o canal escolhido em uma opção de canal.
+func (o *Options) Channel(name string) (*interaction.Channel, bool) {
+	id, ok := o.snowflake(name, interaction.OptionChannel)
+	if !ok || o.resolved == nil {
+		return nil, false
+	}
+	channel, ok := o.resolved.Channels[id]
+	return channel, ok
+}
+
+// Role retorna o cargo escolhido em uma opção de cargo.
+func (o *Options) Role(name string) (*interaction.Role, bool) {
+	id, ok := o.snowflake(name, interaction.OptionRole)
+	if !ok || o.resolved == nil {
+		return nil, false
+	}
+	role, ok := o.resolved.Roles[id]
+	return role, ok
+}
+
+// Attachment retorna o arquivo enviado em uma opção de anexo.
+func (o *Options) Attachment(name string) (*interaction.Attachment, bool) {
+	id, ok := o.snowflake(name, interaction.OptionAttachment)
+	if !ok || o.resolved == nil {
+		return nil, false
+	}
+	attachment, ok := o.resolved.Attachments[id]
+	return attachment, ok
+}
+
+// Focused retorna a opção que o usuário está preenchendo no autocomplete e o texto digitado até agora.
+func (o *Options) Focused() (name string, value string, ok bool) {
+	if o.focused == nil {
+		return "", "", false
+	}
+
+	// O valor parcial normalmente vem como string, mas opções numéricas podem chegar como número
+	if err := json.Unmarshal(o.focused.Value, &value); err != nil {
+		value = string(bytes.Trim(o.focused.Value, `"`))
+	}
+	return o.focused.Name, value, true
+}
+
+// Decodifica o valor de uma opção, conferindo o tipo esperado.
+func (o *Options) decode(name string, target interface{}, optionType interaction.OptionType) bool {
+	value, ok := o.values[name]
+	if !ok || value.Type != optionType {
+		return false
+	}
+	return json.Unmarshal(value.Value, target) == nil
+}
+
+// Retorna o ID (snowflake) guardado em uma opção que referencia um objeto resolvido.
+func (o *Options) snowflake(name string, optionType interaction.OptionType) (string, bool) {
+	var id string
+	return id, o.decode(name, &id, optionType)
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bot-map/interaction"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Comando de administração que define quais cargos podem criar, editar e remover localidades na guilda.
+type PermissionsCommand struct {
+	Policy RolePolicy
+}
+
+// Cria o comando /permissoes com os subcomandos adicionar, remover e listar.
+func NewPermissionsCommand(policy RolePolicy) *CommandInfo {
+	permissionsCmd := &PermissionsCommand{Policy: policy}
+
+	return &CommandInfo{
+		Name:        "permissoes",
+		Description: "Define quais cargos podem criar, editar e remover localidades.",
+		Subcommands: []*SubcommandInfo{
+			{
+				Name:        "adicionar",
+				Description: "Autoriza um cargo a executar uma ação.",
+				Options:     permissionOptions(),
+				Command:     CommandFunc(permissionsCmd.add),
+			},
+			{
+				Name:        "remover",
+				Description: "Retira a autorização de um cargo.",
+				Options:     permissionOptions(),
+				Command:     CommandFunc(permissionsCmd.remove),
+			},
+			{
+				Name:        "listar",
+				Description: "Mostra os cargos autorizados para cada ação.",
+				Command:     CommandFunc(permissionsCmd.list),
+			},
+		},
+		Access:                   &Access{Permissions: PermissionManageGuild},
+		DefaultMemberPermissions: Permissions(PermissionManageGuild),
+		Scope:                    Global(),
+		Contexts:                 []InteractionContext{ContextGuild},
+	}
+}
+
+// Opções de adicionar e remover: a ação e o cargo
+func permissionOptions() []discordgo.ApplicationCommandOption {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(Actions))
+	for _, action := range Actions {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: action, Value: action})
+	}
+
+	return []discordgo.ApplicationCommandOption{
+		{
+			Name:        "acao",
+			Description: "Ação sobre as localidades",
+			Type:        discordgo.ApplicationCommandOptionString,
+			Required:    true,
+			Choices:     choices,
+		},
+		{
+			Name:        "cargo",
+			Description: "Cargo autorizado",
+			Type:        discordgo.ApplicationCommandOptionRole,
+			Required:    true,
+		},
+	}
+}
+
+// Autoriza o cargo para a ação.
+func (c *PermissionsCommand) add(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error) {
+	action, role, err := permissionArgs(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	added, err := c.Policy.AddRole(i.GuildID, action, role.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !added {
+		return interaction.EphemeralMessage(fmt.Sprintf("<@&%s> já pode %s localidades.", role.ID, action)), nil
+	}
+
+	return interaction.EphemeralMessage(fmt.Sprintf("✅ <@&%s> agora pode %s localidades.", role.ID, action)), nil
+}
+
+// Retira a autorização do cargo para a ação.
+func (c *PermissionsCommand) remove(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error) {
+	action, role, err := permissionArgs(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining, err := c.Policy.RemoveRole(i.GuildID, action, role.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("✅ <@&%s> não pode mais %s localidades.", role.ID, action)
+	if len(remaining) == 0 {
+		message += fmt.Sprintf(" Sem cargos configurados, qualquer membro pode %s.", action)
+	}
+	return interaction.EphemeralMessage(message), nil
+}
+
+// Lê a ação e o cargo informados em adicionar e remover.
+func permissionArgs(opts *Options) (string, *interaction.Role, error) {
+	action, okAction := opts.String("acao")
+	role, okRole := opts.Role("cargo")
+	if !okAction || !okRole {
+		return "", nil, fmt.Errorf("faltam argumentos! Use: /permissoes <adicionar|remover> <ação> <cargo>")
+	}
+	if !slices.Contains(Actions, action) {
+		return "", nil, fmt.Errorf("ação desconhecida: %s", action)
+	}
+	return action, role, nil
+}
+
+// Lista os cargos autorizados de cada ação.
+func (c *PermissionsCommand) list(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error) {
+	responseText := "**Cargos autorizados:**\n"
+
+	for _, action := range Actions {
+		roles := c.Policy.Roles(i.GuildID, action)
+		if len(roles) == 0 {
+			responseText += fmt.Sprintf("- %s: qualquer membro\n", action)
+			continue
+		}
+
+		sort.Strings(roles)
+		mentions := ""
+		for _, role := range roles {
+			mentions += fmt.Sprintf(" <@&%s>", role)
+		}
+		responseText += fmt.Sprintf("- %s:%s\n", action, mentions)
+	}
+
+	return interaction.EphemeralMessage(responseText), nil
+}
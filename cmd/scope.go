@@ -0,0 +1,68 @@
+package cmd
+
+// ScopeKind indica onde um comando é registrado no Discord.
+type ScopeKind int
+
+const (
+	ScopeDevGuild ScopeKind = iota // Apenas na guilda de desenvolvimento (Config.GuildID); padrão
+	ScopeGlobal                    // Em todas as guildas e DMs
+	ScopeGuilds                    // Em uma lista de guildas
+)
+
+// CommandScope define onde um comando fica disponível. O valor zero registra o comando
+// apenas na guilda de desenvolvimento.
+type CommandScope struct {
+	Kind     ScopeKind // Tipo do escopo
+	GuildIDs []string  // Guildas do comando quando Kind é ScopeGuilds
+}
+
+// Global registra o comando globalmente.
+func Global() CommandScope {
+	return CommandScope{Kind: ScopeGlobal}
+}
+
+// Guilds registra o comando nas guildas informadas.
+func Guilds(guildIDs ...string) CommandScope {
+	return CommandScope{Kind: ScopeGuilds, GuildIDs: guildIDs}
+}
+
+// DevGuild registra o comando apenas na guilda de desenvolvimento.
+func DevGuild() CommandScope {
+	return CommandScope{Kind: ScopeDevGuild}
+}
+
+// InteractionContext indica onde um comando global pode ser usado.
+type InteractionContext int
+
+const (
+	ContextGuild          InteractionContext = 0 // Dentro de guildas
+	ContextBotDM          InteractionContext = 1 // Na DM com o bot
+	ContextPrivateChannel InteractionContext = 2 // Em DMs e grupos privados (apps instalados no usuário)
+)
+
+// IntegrationType indica em que tipo de instalação um comando global fica disponível.
+type IntegrationType int
+
+const (
+	IntegrationGuildInstall IntegrationType = 0 // App instalado na guilda
+	IntegrationUserInstall  IntegrationType = 1 // App instalado no usuário
+)
+
+// Permissões usadas em DefaultMemberPermissions.
+const (
+	PermissionAdministrator  int64 = 1 << 3
+	PermissionManageChannels int64 = 1 << 4
+	PermissionManageGuild    int64 = 1 << 5
+	PermissionManageMessages int64 = 1 << 13
+	PermissionManageRoles    int64 = 1 << 28
+)
+
+// Permissions cria o valor de DefaultMemberPermissions a partir das permissões informadas.
+// Sem permissões, o comando fica restrito aos administradores.
+func Permissions(permissions ...int64) *int64 {
+	var bits int64
+	for _, permission := range permissions {
+		bits |= permission
+	}
+	return &bits
+}
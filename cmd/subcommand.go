@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"bot-map/interaction"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// SubcommandInfo descreve um subcomando (folha), como /local adicionar.
+// Cada folha tem as próprias opções e o próprio Command, que também pode implementar AutocompleteCommand.
+type SubcommandInfo struct {
+	Name        string                               // Nome do subcomando
+	Description string                               // Descrição do subcomando
+	Options     []discordgo.ApplicationCommandOption // Opções do subcomando
+	Command     Command                              // Implementação do subcomando
+	Middleware  []Middleware                         // Middlewares do subcomando, aplicados depois dos do comando
+	Cooldowns   []Cooldown                           // Limites de uso do subcomando
+	Access      *Access                              // Quem pode usar o subcomando, além das exigências do comando
+}
+
+// SubcommandGroup agrupa subcomandos sob um nome, como /local tag adicionar.
+type SubcommandGroup struct {
+	Name        string            // Nome do grupo
+	Description string            // Descrição do grupo
+	Subcommands []*SubcommandInfo // Subcomandos do grupo
+}
+
+// Route é o destino de uma interação depois de percorrer comando, grupo e subcomando.
+type Route struct {
+	Path     []string                             // Nomes percorridos (ex.: ["local", "tag", "adicionar"])
+	Command  Command                              // Implementação da folha
+	Declared []discordgo.ApplicationCommandOption // Opções declaradas na folha
+
+	values     []*interaction.OptionValue // Opções enviadas para a folha
+	middleware []Middleware               // Middlewares do comando e da folha
+	cooldowns  []routeCooldown            // Cooldowns do comando e da folha
+	access     []Access                   // Exigências de acesso do comando e da folha
+}
+
+// Name retorna o caminho completo da rota, como "local tag adicionar".
+func (r *Route) Name() string {
+	return strings.Join(r.Path, " ")
+}
+
+// Options valida as opções enviadas para a folha e monta o acesso por nome.
+func (r *Route) Options(i *interaction.Interaction) (*Options, error) {
+	return newOptions(r.Declared, r.values, i)
+}
+
+// HasSubcommands indica se o comando é só um agrupador de subcomandos.
+func (info *CommandInfo) HasSubcommands() bool {
+	return len(info.Subcommands) > 0 || len(info.Groups) > 0
+}
+
+// ApplicationOptions monta a árvore de opções enviada ao Discord: grupos e subcomandos
+// viram opções dos tipos SUB_COMMAND_GROUP e SUB_COMMAND com as opções das folhas dentro.
+func (info *CommandInfo) ApplicationOptions() []discordgo.ApplicationCommandOption {
+	if !info.HasSubcommands() {
+		return info.Options
+	}
+
+	var options []discordgo.ApplicationCommandOption
+	for _, sub := range info.Subcommands {
+		options = append(options, subcommandOption(sub))
+	}
+
+	for _, group := range info.Groups {
+		groupOption := discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+			Name:        group.Name,
+			Description: group.Description,
+		}
+		for _, sub := range group.Subcommands {
+			option := subcommandOption(sub)
+			groupOption.Options = append(groupOption.Options, &option)
+		}
+		options = append(options, groupOption)
+	}
+
+	return options
+}
+
+// Converte um subcomando na opção do tipo SUB_COMMAND.
+func subcommandOption(sub *SubcommandInfo) discordgo.ApplicationCommandOption {
+	option := discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionSubCommand,
+		Name:        sub.Name,
+		Description: sub.Description,
+	}
+	for idx := range sub.Options {
+		option.Options = append(option.Options, &sub.Options[idx])
+	}
+	return option
+}
+
+// Resolve encontra a folha que deve tratar a interação, descendo por grupo e subcomando.
+func (cr *CommandRegistry) Resolve(i *interaction.Interaction) (*Route, error) {
+	info, exists := cr.GetCommand(i.CommandName())
+	if !exists {
+		return nil, fmt.Errorf("comando desconhecido: %s", i.CommandName())
+	}
+
+	var values []*interaction.OptionValue
+	if i.Data != nil {
+		values = i.Data.Options
+	}
+
+	route := &Route{Path: []string{info.Name}, middleware: info.Middleware}
+	for _, cooldown := range info.Cooldowns {
+		route.cooldowns = append(route.cooldowns, routeCooldown{name: info.Name, cooldown: cooldown})
+	}
+	if info.Access != nil {
+		route.access = append(route.access, *info.Access)
+	}
+
+	// Comando simples: a própria raiz é a folha
+	if !info.HasSubcommands() {
+		route.Command = info.Command
+		route.Declared = info.Options
+		route.values = values
+		return route, nil
+	}
+
+	if len(values) != 1 {
+		return nil, fmt.Errorf("comando %s exige um subcomando", info.Name)
+	}
+	selected := values[0]
+
+	subcommands := info.Subcommands
+	if selected.Type == interaction.OptionSubCommandGroup {
+		group := findGroup(info.Groups, selected.Name)
+		if group == nil {
+			return nil, fmt.Errorf("grupo desconhecido: %s %s", info.Name, selected.Name)
+		}
+		route.Path = append(route.Path, group.Name)
+
+		if len(selected.Options) != 1 {
+			return nil, fmt.Errorf("grupo %s exige um subcomando", route.Name())
+		}
+		subcommands = group.Subcommands
+		selected = selected.Options[0]
+	}
+
+	if selected.Type != interaction.OptionSubCommand {
+		return nil, fmt.Errorf("comando %s exige um subcomando", route.Name())
+	}
+
+	sub := findSubcommand(subcommands, selected.Name)
+	if sub == nil {
+		return nil, fmt.Errorf("subcomando desconhecido: %s %s", route.Name(), selected.Name)
+	}
+
+	route.Path = append(route.Path, sub.Name)
+	route.Command = sub.Command
+	route.Declared = sub.Options
+	route.middleware = append(route.middleware[:len(route.middleware):len(route.middleware)], sub.Middleware...)
+	for _, cooldown := range sub.Cooldowns {
+		route.cooldowns = append(route.cooldowns, routeCooldown{name: route.Name(), cooldown: cooldown})
+	}
+	if sub.Access != nil {
+		route.access = append(route.access, *sub.Access)
+	}
+	route.values = selected.Options
+	return route, nil
+}
+
+// Procura um grupo pelo nome.
+func findGroup(groups []*SubcommandGroup, name string) *SubcommandGroup {
+	for _, group := range groups {
+		if group.Name == name {
+			return group
+		}
+	}
+	return nil
+}
+
+// Procura um subcomando pelo nome.
+func findSubcommand(subcommands []*SubcommandInfo, name string) *SubcommandInfo {
+	for _, sub := range subcommands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bot-map/interaction"
+	"bot-map/store"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Comando de administração que copia ou move as localidades de um namespace (guilda ou DM) para outro.
+type TransferCommand struct {
+	Localidades store.LocationStore
+}
+
+// Cria o comando /transferir com os subcomandos copiar e mover. Como mexe em guildas diferentes,
+// só os donos do bot podem usá-lo, e ele fica registrado apenas na guilda de desenvolvimento.
+func NewTransferCommand(localidades store.LocationStore) *CommandInfo {
+	transferCmd := &TransferCommand{Localidades: localidades}
+
+	return &CommandInfo{
+		Name:        "transferir",
+		Description: "Copia ou move as localidades entre servidores.",
+		Subcommands: []*SubcommandInfo{
+			{
+				Name:        "copiar",
+				Description: "Copia as localidades de um servidor para outro.",
+				Options:     transferOptions(),
+				Command: CommandFunc(func(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error) {
+					return transferCmd.transfer(opts, false)
+				}),
+			},
+			{
+				Name:        "mover",
+				Description: "Move as localidades de um servidor para outro.",
+				Options:     transferOptions(),
+				Command: CommandFunc(func(ctx context.Context, i *interaction.Interaction, opts *Options) (*interaction.Response, error) {
+					return transferCmd.transfer(opts, true)
+				}),
+			},
+		},
+		Access:                   &Access{OwnersOnly: true},
+		DefaultMemberPermissions: Permissions(PermissionAdministrator),
+		Scope:                    DevGuild(),
+	}
+}
+
+// Opções de origem e destino
+func transferOptions() []discordgo.ApplicationCommandOption {
+	return []discordgo.ApplicationCommandOption{
+		{
+			Name:        "origem",
+			Description: "ID do servidor de origem (ou user:<id> para as localidades de DM de um usuário)",
+			Type:        discordgo.ApplicationCommandOptionString,
+			Required:    true,
+		},
+		{
+			Name:        "destino",
+			Description: "ID do servidor de destino (ou user:<id>)",
+			Type:        discordgo.ApplicationCommandOptionString,
+			Required:    true,
+		},
+	}
+}
+
+// Copia (ou move) as localidades; as que já existem no destino são mantidas
+func (c *TransferCommand) transfer(opts *Options, move bool) (*interaction.Response, error) {
+	origemValue, _ := opts.String("origem")
+	destinoValue, _ := opts.String("destino")
+
+	origem, err := store.ParseNamespace(origemValue)
+	if err != nil {
+		return nil, err
+	}
+	destino, err := store.ParseNamespace(destinoValue)
+	if err != nil {
+		return nil, err
+	}
+	if origem == destino {
+		return nil, fmt.Errorf("origem e destino são iguais")
+	}
+
+	result, err := c.Localidades.Transfer(origem, destino, move)
+	if err != nil {
+		return nil, err
+	}
+
+	verbo := "copiadas"
+	if move {
+		verbo = "movidas"
+	}
+
+	responseText := fmt.Sprintf("📦 %d localidades %s de `%s` para `%s`.", len(result.Copied), verbo, origem, destino)
+	if len(result.Skipped) > 0 {
+		responseText += fmt.Sprintf("\n⚠️ Já existiam no destino e foram mantidas: %s", strings.Join(result.Skipped, ", "))
+	}
+
+	return interaction.EphemeralMessage(responseText), nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -14,6 +15,13 @@ type Config struct {
 	ApplicationID string
 	GuildID       string
 	GatewayURL    string
+
+	GatewayCompress   bool // Usa o transporte zlib-stream no gateway
+	CommandSyncDryRun bool // Apenas mostra as diferenças na sincronização dos comandos, sem aplicá-las
+
+	OwnerIDs []string // IDs dos donos do bot, que têm acesso a todos os comandos
+
+	StorageDir string // Diretório onde as localidades e os cargos autorizados são gravados; vazio guarda apenas em memória
 }
 
 func LoadConfig() *Config {
@@ -28,7 +36,33 @@ func LoadConfig() *Config {
 		ApplicationID: os.Getenv("APPLICATION_ID"),
 		GuildID:       os.Getenv("GUILD_ID"),
 		GatewayURL:    os.Getenv("GATEWAY_URL"),
+
+		GatewayCompress:   os.Getenv("GATEWAY_COMPRESS") == "true",
+		CommandSyncDryRun: os.Getenv("COMMAND_SYNC_DRY_RUN") == "true",
+
+		OwnerIDs: splitList(os.Getenv("OWNER_IDS")),
+
+		StorageDir: getEnvDefault("STORAGE_DIR", "data"),
+	}
+}
+
+// Lê a variável de ambiente, usando o valor padrão quando ela não está definida
+func getEnvDefault(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// Separa uma lista de valores separados por vírgula, ignorando itens vazios
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
 
 // Armazenas as conf do bot (urls e token)
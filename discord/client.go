@@ -4,10 +4,13 @@ import (
 	"bot-map/cmd"
 	"bot-map/config"
 	"bot-map/shared"
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -22,20 +25,70 @@ type WebsocketConn interface {
 
 // Estrutura que representa o cliente do Discord, contendo configurações, conexão WebSocket e registro de comandos.
 type DiscordClient struct {
-	Client    shared.HTTPClient    // Cliente HTTP para requisições
-	Config    *config.Config       // Configurações do bot
-	WsConn    WebsocketConn        // Conexão WebSocket com o gateway do Discord
-	Heartbeat time.Duration        // Intervalo de tempo para envio de heartbeat
-	Sequence  int                  // Número da sequência de eventos recebidos
-	Registry  *cmd.CommandRegistry // Registro de comandos disponíveis
+	*EventBus // Handlers dos eventos do gateway (OnMessageCreate, OnGuildCreate...)
+
+	Client    shared.HTTPClient     // Cliente HTTP para requisições
+	Rest      *RestClient           // Chamadas REST com controle de rate limit
+	Config    *config.Config        // Configurações do bot
+	WsConn    WebsocketConn         // Conexão WebSocket com o gateway do Discord
+	Heartbeat time.Duration         // Intervalo de tempo para envio de heartbeat
+	Registry  *cmd.CommandRegistry  // Registro de comandos disponíveis
+	Intents   int                   // Intents enviados no IDENTIFY
+	Logger    *log.Logger           // Logger do cliente
+	Clock     Clock                 // Relógio usado para heartbeats, backoff e limites
+	Responder *InteractionResponder // Entrega as respostas dos comandos ao Discord
+
+	ShutdownTimeout time.Duration // Tempo máximo de espera pelos comandos em andamento no desligamento
+	AutoDeferAfter  time.Duration // Tempo após o qual um comando ainda em execução recebe uma resposta adiada
+
+	// Função usada para abrir a conexão WebSocket; se nil, usa o dialer padrão do gorilla/websocket.
+	// Permite que testes entreguem uma WebsocketConn mockada à máquina de estados do gateway.
+	Dial func(gatewayURL string) (WebsocketConn, error)
+
+	ShardID    int // Índice do shard atendido por este cliente
+	ShardCount int // Total de shards; zero quando o bot roda sem sharding
+
+	identifyGate func(ctx context.Context, shardID int) error // Aguarda a vez de cada IDENTIFY para respeitar os limites de início de sessão
+
+	heartbeatMu      sync.Mutex    // Protege o estado de acompanhamento dos heartbeats
+	heartbeatAcked   bool          // Indica se o último heartbeat enviado recebeu ACK (op 11)
+	lastHeartbeatAt  time.Time     // Momento do envio do último heartbeat
+	lastHeartbeatAck time.Time     // Momento do último ACK recebido
+	latency          time.Duration // Tempo de ida e volta do último heartbeat
+
+	mu               sync.Mutex     // Protege o estado da sessão e o writer atual
+	sequence         int            // Número da sequência de eventos recebidos
+	sessionID        string         // ID da sessão recebido no READY, usado para retomar a conexão
+	resumeGatewayURL string         // URL do gateway recebida no READY para retomar a sessão
+	writer           *gatewayWriter // Único responsável por escrever na conexão atual
+	state            ShardState     // Estado atual da conexão com o gateway
+
+	eventCtx      context.Context                   // Contexto entregue aos handlers de eventos
+	cancel        context.CancelFunc                // Cancela o contexto de Run
+	done          chan struct{}                     // Fechado quando Run retorna
+	runErr        error                             // Resultado do último Run
+	closing       bool                              // Indica que o cliente está desligando e não aceita novos dispatches
+	inflight      sync.WaitGroup                    // Dispatches em andamento
+	events        eventQueue                        // Fila que entrega os eventos ao EventBus em ordem
+	shutdownHooks []func(ctx context.Context) error // Executados no desligamento, depois dos comandos em andamento
 }
 
-// Estrutura que representa um payload enviado para o gateway do Discord.
+// Estrutura que representa um payload trocado com o gateway do Discord.
+// Os dados ficam crus para serem decodificados uma única vez, já no tipo do evento.
 type GatewayPayload struct {
-	Op int         `json:"op"`          // Código da operação
-	D  interface{} `json:"d"`           // Dados da operação
-	S  *int        `json:"s,omitempty"` // Número da sequência (opcional)
-	T  *string     `json:"t,omitempty"` // Tipo do evento (opcional)
+	Op int             `json:"op"`          // Código da operação
+	D  json.RawMessage `json:"d"`           // Dados da operação
+	S  *int            `json:"s,omitempty"` // Número da sequência (opcional)
+	T  *string         `json:"t,omitempty"` // Tipo do evento (opcional)
+}
+
+// Monta um payload para enviar ao gateway, codificando os dados da operação.
+func NewGatewayPayload(op int, data interface{}) (GatewayPayload, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return GatewayPayload{}, fmt.Errorf("could not encode op %d payload: %w", op, err)
+	}
+	return GatewayPayload{Op: op, D: encoded}, nil
 }
 
 // Estrutura que representa o evento "Hello" do Discord, contendo o intervalo do heartbeat.
@@ -45,164 +98,273 @@ type HelloEvent struct {
 
 // Estrutura para o payload de identificação do bot no gateway.
 type IdentifyData struct {
-	Token      string            `json:"token"`      // Token do bot
-	Properties map[string]string `json:"properties"` // Propriedades do cliente
-	Intents    int               `json:"intents"`    // Intenções (eventos que o bot quer receber)
+	Token      string            `json:"token"`           // Token do bot
+	Properties map[string]string `json:"properties"`      // Propriedades do cliente
+	Intents    int               `json:"intents"`         // Intenções (eventos que o bot quer receber)
+	Shard      *[2]int           `json:"shard,omitempty"` // Par [shard_id, num_shards], quando há sharding
 }
 
-// Instância única do cliente do Discord (Singleton).
-var discordClientInstace *DiscordClient
-
-// Função para obter a instância do cliente do Discord, garantindo que apenas uma seja criada.
-func GetDiscordClient(config *config.Config, registry *cmd.CommandRegistry, client ...shared.HTTPClient) *DiscordClient {
-	if discordClientInstace == nil {
-		discordClientInstace = &DiscordClient{
-			Client: func() shared.HTTPClient {
-				if len(client) > 0 {
-					return client[0]
-				}
-				return &http.Client{}
-			}(),
-			Config:   config,
-			Registry: registry,
-		}
-	}
-	return discordClientInstace
+// Estrutura para o payload de retomada de sessão (op 6) no gateway.
+type ResumeData struct {
+	Token     string `json:"token"`      // Token do bot
+	SessionID string `json:"session_id"` // ID da sessão que será retomada
+	Seq       int    `json:"seq"`        // Último número de sequência recebido
 }
 
-// Função para registrar comandos slash no Discord.
-func (dc *DiscordClient) RegisterSlashCommands() {
-	commands := dc.Registry.GetAllCommands()
-	for _, cmdInfo := range commands {
-
-		command := map[string]interface{}{
-			"name":        cmdInfo.Name,
-			"description": cmdInfo.Description,
-			"options":     cmdInfo.Options,
-		}
-
-		jsonCommand, _ := json.Marshal(command)
-
-		// URL para registrar comandos no servidor do Discord
-		url := fmt.Sprintf("%s/applications/%s/guilds/%s/commands", dc.Config.BaseURL, dc.Config.ApplicationID, dc.Config.GuildID)
-
-		req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonCommand))
+// Cria um novo cliente do Discord. Cada cliente é independente e controla o próprio ciclo de vida,
+// então um mesmo processo pode manter vários bots (ex.: staging e produção) lado a lado.
+func NewDiscordClient(config *config.Config, registry *cmd.CommandRegistry, opts ...Option) *DiscordClient {
+	dc := &DiscordClient{
+		Client:   &http.Client{},
+		Config:   config,
+		Registry: registry,
+		Intents:  DefaultIntents,
+		Logger:   defaultLogger(),
+		Clock:    systemClock{},
+		EventBus: NewEventBus(),
+
+		ShutdownTimeout: defaultShutdownTimeout,
+		AutoDeferAfter:  defaultAutoDeferAfter,
+	}
 
-		req.Header.Set("Authorization", "Bot "+dc.Config.Token)
-		req.Header.Set("Content-Type", "application/json")
+	for _, opt := range opts {
+		opt(dc)
+	}
 
-		resp, err := dc.Client.Do(req)
-		if err != nil {
-			fmt.Println("error")
-		}
-		defer resp.Body.Close()
+	// O cliente REST e o responder usam o cliente HTTP final, depois de aplicadas as opções
+	if dc.Rest == nil {
+		dc.Rest = NewRestClient(dc.Config, dc.Client, dc.Clock, dc.Logger)
 	}
+	if dc.Responder == nil {
+		dc.Responder = NewInteractionResponder(dc.Rest)
+	}
+
+	return dc
 }
 
-// Função para conectar ao gateway do Discord via WebSocket.
+// Função para conectar ao gateway do Discord via WebSocket (dial, HELLO e IDENTIFY/RESUME).
+// Não inicia o heartbeat nem a leitura de eventos: quem mantém a conexão viva é o loop de Run.
+// Se já existe uma sessão, tenta retomá-la (op 6) em vez de enviar um novo IDENTIFY.
 func (dc *DiscordClient) ConnectToGateway() error {
 	gatewayURL := dc.Config.GatewayURL
 
+	// Usa a URL de retomada recebida no READY quando existe uma sessão anterior
+	sessionID, resumeGatewayURL, _ := dc.session()
+	resuming := sessionID != "" && resumeGatewayURL != ""
+	if resuming {
+		gatewayURL = dc.resumeURL(resumeGatewayURL)
+	}
+
 	// Cria a conexão WebSocket
-	dialer := websocket.DefaultDialer
-	ws, _, err := dialer.Dial(gatewayURL, nil)
+	ws, err := dc.dial(gatewayURL)
 	if err != nil {
 		return err
 	}
 
 	dc.WsConn = ws
 
-	fmt.Println("connected")
+	dc.logf("connected")
 
 	// Lê o primeiro payload recebido do gateway
 	var payload GatewayPayload
-	dc.WsConn.ReadJSON(&payload)
+	if err := dc.WsConn.ReadJSON(&payload); err != nil {
+		dc.WsConn.Close()
+		return err
+	}
 
 	// Verifica se a operação recebida é "Hello" (código 10)
-	if payload.Op != 10 {
+	if payload.Op != OpHello {
+		dc.WsConn.Close()
 		return fmt.Errorf("op code unexpected: %d", payload.Op)
 	}
 
 	var hello HelloEvent
 
 	// Converte os dados do payload para a estrutura HelloEvent
-	data, err := json.Marshal(payload.D)
-	if err != nil {
-		return err
-	}
-
-	if err := json.Unmarshal(data, &hello); err != nil {
+	if err := json.Unmarshal(payload.D, &hello); err != nil {
+		dc.WsConn.Close()
 		return err
 	}
 
 	// Define o intervalo do heartbeat com base nos dados recebidos
 	dc.Heartbeat = time.Duration(hello.HeartbeatInterval) * time.Millisecond
+	dc.resetHeartbeat()
+
+	// A partir daqui toda escrita na conexão passa pela fila do writer
+	dc.startWriter(ws)
 
-	// Define os intents do bot (quais eventos ele irá receber)
-	const (
-		IntentsGuilds         = 1 << 0  // Eventos de guildas
-		IntentsGuildMessages  = 1 << 9  // Mensagens enviadas nas guildas
-		IntentsMessageContent = 1 << 15 // Conteúdo das mensagens
-	)
+	// Retoma a sessão anterior ou se identifica do zero
+	if resuming {
+		err = dc.Resume()
+	} else {
+		err = dc.Identify()
+	}
+	if err != nil {
+		dc.stopWriter()
+		dc.WsConn.Close()
+		return err
+	}
 
+	return nil
+}
+
+// Função que envia o payload de identificação (op 2) para autenticar no gateway do Discord.
+// A vez no bucket de identificação é aguardada antes da conexão, em waitIdentify.
+func (dc *DiscordClient) Identify() error {
 	// Cria o payload de identificação para autenticar no gateway do Discord
-	identifyPayload := GatewayPayload{
-		Op: 2,
-		D: IdentifyData{
-			Token: dc.Config.Token,
-			Properties: map[string]string{
-				"os":      "linux",
-				"browser": "my_bot",
-				"device":  "my_bot",
-			},
-			Intents: IntentsGuilds | IntentsGuildMessages | IntentsMessageContent,
+	identify := IdentifyData{
+		Token: dc.Config.Token,
+		Properties: map[string]string{
+			"os":      "linux",
+			"browser": "my_bot",
+			"device":  "my_bot",
 		},
+		Intents: dc.Intents,
+	}
+
+	// Informa qual shard esta conexão atende
+	if dc.ShardCount > 0 {
+		identify.Shard = &[2]int{dc.ShardID, dc.ShardCount}
+	}
+
+	identifyPayload, err := NewGatewayPayload(OpIdentify, identify)
+	if err != nil {
+		return err
 	}
 
 	// Envia o payload de identificação
-	if err := dc.WsConn.WriteJSON(identifyPayload); err != nil {
+	return dc.Send(identifyPayload)
+}
+
+// Função que envia o payload de retomada (op 6), pedindo ao Discord os eventos perdidos desde a última sequência.
+func (dc *DiscordClient) Resume() error {
+	sessionID, _, sequence := dc.session()
+
+	resumePayload, err := NewGatewayPayload(OpResume, ResumeData{
+		Token:     dc.Config.Token,
+		SessionID: sessionID,
+		Seq:       sequence,
+	})
+	if err != nil {
 		return err
 	}
 
-	// Inicia o envio de heartbeats e o tratamento de eventos em goroutines
-	go dc.StartHeartbeating()
-	go dc.HandleEvents()
+	dc.logf("resuming session %s", sessionID)
 
-	return nil
+	return dc.Send(resumePayload)
+}
+
+// Aguarda a vez no bucket de identificação, quando gerenciado por um ShardManager.
+// Roda antes de abrir a conexão: esperar depois do HELLO deixaria a conexão sem heartbeat
+// e o gateway a derrubaria. Conexões que vão retomar a sessão não enviam IDENTIFY e não esperam.
+func (dc *DiscordClient) waitIdentify(ctx context.Context) error {
+	if dc.identifyGate == nil {
+		return nil
+	}
+	if sessionID, resumeGatewayURL, _ := dc.session(); sessionID != "" && resumeGatewayURL != "" {
+		return nil
+	}
+	return dc.identifyGate(ctx, dc.ShardID)
 }
 
-// Função para enviar heartbeats periodicamente para manter a conexão ativa.
-func (dc *DiscordClient) StartHeartbeating() error {
-	ticker := time.NewTicker(dc.Heartbeat)
-	defer ticker.Stop()
+// Sequence retorna o último número de sequência recebido do gateway.
+func (dc *DiscordClient) Sequence() int {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return dc.sequence
+}
 
-	for range ticker.C {
-		heartbeat := GatewayPayload{
-			Op: 1,
-			D:  dc.Sequence,
-		}
+// SessionID retorna o ID da sessão atual do gateway (vazio antes do READY).
+func (dc *DiscordClient) SessionID() string {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return dc.sessionID
+}
 
-		if err := dc.WsConn.WriteJSON(heartbeat); err != nil {
-			return err
-		}
+// Função que descarta a sessão atual, forçando um novo IDENTIFY na próxima conexão.
+func (dc *DiscordClient) ResetSession() {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.sessionID = ""
+	dc.resumeGatewayURL = ""
+	dc.sequence = 0
+}
 
-		fmt.Println("Heartbeat sent")
+// Retorna uma cópia consistente do estado da sessão.
+func (dc *DiscordClient) session() (sessionID, resumeGatewayURL string, sequence int) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return dc.sessionID, dc.resumeGatewayURL, dc.sequence
+}
+
+// Guarda os dados de sessão recebidos no READY.
+func (dc *DiscordClient) setSession(sessionID, resumeGatewayURL string) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.sessionID = sessionID
+	dc.resumeGatewayURL = resumeGatewayURL
+}
+
+// Atualiza o número de sequência com o valor recebido em um dispatch.
+func (dc *DiscordClient) setSequence(sequence int) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.sequence = sequence
+}
+
+// Abre a conexão WebSocket usando o Dial configurado ou o dialer padrão.
+// Com GatewayCompress ativo, pede o transporte zlib-stream; um Dial customizado recebe a URL
+// já com compress=zlib-stream e deve devolver a conexão envolvida por NewZlibStreamConn.
+func (dc *DiscordClient) dial(gatewayURL string) (WebsocketConn, error) {
+	if dc.Config.GatewayCompress {
+		gatewayURL = withZlibStream(gatewayURL)
 	}
 
-	return nil
+	if dc.Dial != nil {
+		return dc.Dial(gatewayURL)
+	}
+
+	ws, _, err := websocket.DefaultDialer.Dial(gatewayURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &gorillaConn{Conn: ws}
+	if dc.Config.GatewayCompress {
+		return NewZlibStreamConn(conn), nil
+	}
+	return conn, nil
 }
 
-// Função para tratar erros e tentar reconectar ao gateway.
-func (dc *DiscordClient) HandleError(err error) {
-	fmt.Println("error detected", err)
+// Monta a URL de retomada mantendo os parâmetros (versão, encoding) da URL configurada.
+func (dc *DiscordClient) resumeURL(resumeGatewayURL string) string {
+	return withGatewayQuery(resumeGatewayURL, dc.Config.GatewayURL)
+}
 
-	// Fecha a conexão WebSocket ao detectar um erro
-	if closeErr := dc.WsConn.Close(); closeErr != nil {
-		fmt.Println("could not close connection: ", closeErr)
+// Aplica à URL informada os parâmetros da URL base (versão, encoding) quando ela não tiver nenhum.
+func withGatewayQuery(rawURL, baseURL string) string {
+	gatewayURL, err := url.Parse(rawURL)
+	if err != nil {
+		return baseURL
+	}
+
+	if gatewayURL.Path == "" {
+		gatewayURL.Path = "/"
+	}
+
+	if base, err := url.Parse(baseURL); err == nil && gatewayURL.RawQuery == "" {
+		gatewayURL.RawQuery = base.RawQuery
 	}
 
-	// Tenta se reconectar ao gateway
-	dc.ConnectToGateway()
+	return gatewayURL.String()
+}
+
+// Escreve no log do cliente, identificando o shard quando há sharding.
+func (dc *DiscordClient) logf(format string, args ...interface{}) {
+	if dc.ShardCount > 0 {
+		format = fmt.Sprintf("[shard %d] ", dc.ShardID) + format
+	}
+	dc.Logger.Printf(format, args...)
 }
 
 // Basicamente esse codigo (cliente.go) gerencia as comunicacoes entre bot e disc via WebSocket
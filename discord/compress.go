@@ -0,0 +1,118 @@
+package discord
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// Sufixo Z_SYNC_FLUSH que marca o fim de cada mensagem no modo zlib-stream.
+var zlibSuffix = []byte{0x00, 0x00, 0xff, 0xff}
+
+// Tamanho da janela do deflate; é o histórico que as próximas mensagens podem referenciar.
+const inflateWindowSize = 32 * 1024
+
+// MessageConn é uma conexão que entrega as mensagens cruas do WebSocket.
+// É a base para a descompressão zlib-stream e permite que testes entreguem fixtures comprimidas.
+type MessageConn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteJSON(v interface{}) error
+	Close() error
+}
+
+// zlibStreamConn descomprime o transporte zlib-stream do gateway.
+// Todas as mensagens da conexão formam um único stream zlib; cada mensagem termina com Z_SYNC_FLUSH.
+type zlibStreamConn struct {
+	MessageConn
+	pending  []byte        // Dados comprimidos acumulados até o sufixo Z_SYNC_FLUSH
+	inflater io.ReadCloser // Descompressor reaproveitado entre as mensagens
+	window   []byte        // Últimos 32KB descomprimidos, usados como dicionário da próxima mensagem
+	header   bool          // Indica se o cabeçalho zlib do início do stream já foi lido
+}
+
+// Cria uma WebsocketConn que descomprime as mensagens recebidas no modo zlib-stream.
+// Os envios continuam em JSON puro, como exige o Discord.
+func NewZlibStreamConn(conn MessageConn) WebsocketConn {
+	return &zlibStreamConn{MessageConn: conn}
+}
+
+// Lê mensagens até completar um payload (sufixo Z_SYNC_FLUSH), descomprime e decodifica o JSON.
+func (c *zlibStreamConn) ReadJSON(v interface{}) error {
+	for {
+		_, message, err := c.MessageConn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		c.pending = append(c.pending, message...)
+		if bytes.HasSuffix(c.pending, zlibSuffix) {
+			break
+		}
+	}
+
+	data, err := c.inflate(c.pending)
+	c.pending = c.pending[:0]
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// Repassa o fechamento com código para a conexão de baixo, quando ela suporta.
+func (c *zlibStreamConn) CloseWithCode(code int, reason string) error {
+	if conn, ok := c.MessageConn.(closeCoder); ok {
+		return conn.CloseWithCode(code, reason)
+	}
+	return c.MessageConn.Close()
+}
+
+// Descomprime um payload completo. Como o Z_SYNC_FLUSH deixa o stream alinhado em bytes,
+// o descompressor é reiniciado a cada mensagem usando o histórico anterior como dicionário.
+func (c *zlibStreamConn) inflate(compressed []byte) ([]byte, error) {
+	if !c.header {
+		if len(compressed) < 2 || compressed[0]&0x0f != 8 || (int(compressed[0])<<8|int(compressed[1]))%31 != 0 {
+			return nil, errors.New("invalid zlib-stream header")
+		}
+		compressed = compressed[2:]
+		c.header = true
+	}
+
+	reader := bytes.NewReader(compressed)
+	if c.inflater == nil {
+		c.inflater = flate.NewReaderDict(reader, c.window)
+	} else if err := c.inflater.(flate.Resetter).Reset(reader, c.window); err != nil {
+		return nil, err
+	}
+
+	// O stream nunca termina com um bloco final, então o fim da mensagem aparece como ErrUnexpectedEOF
+	data, err := io.ReadAll(c.inflater)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("zlib-stream inflate: %w", err)
+	}
+
+	c.window = append(c.window, data...)
+	if len(c.window) > inflateWindowSize {
+		c.window = append([]byte(nil), c.window[len(c.window)-inflateWindowSize:]...)
+	}
+
+	return data, nil
+}
+
+// Acrescenta compress=zlib-stream à URL do gateway.
+func withZlibStream(gatewayURL string) string {
+	parsed, err := url.Parse(gatewayURL)
+	if err != nil {
+		return gatewayURL
+	}
+
+	query := parsed.Query()
+	query.Set("compress", "zlib-stream")
+	parsed.RawQuery = query.Encode()
+
+	return parsed.String()
+}
@@ -0,0 +1,114 @@
+package discord
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// Conexão falsa que entrega as mensagens cruas na ordem recebida.
+type fakeMessageConn struct {
+	frames [][]byte
+}
+
+func (c *fakeMessageConn) ReadMessage() (int, []byte, error) {
+	if len(c.frames) == 0 {
+		return 0, nil, io.EOF
+	}
+	frame := c.frames[0]
+	c.frames = c.frames[1:]
+	return websocket.BinaryMessage, frame, nil
+}
+
+func (c *fakeMessageConn) WriteJSON(v interface{}) error { return nil }
+func (c *fakeMessageConn) Close() error                  { return nil }
+
+// Comprime os payloads como o gateway faz no modo zlib-stream: um único stream zlib,
+// com um Z_SYNC_FLUSH no fim de cada payload.
+func zlibStreamFixture(t *testing.T, payloads ...string) [][]byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := zlib.NewWriter(&buf)
+
+	var messages [][]byte
+	for _, p := range payloads {
+		if _, err := writer.Write([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, append([]byte(nil), buf.Bytes()...))
+		buf.Reset()
+	}
+	return messages
+}
+
+func TestZlibStreamConnSplitFrames(t *testing.T) {
+	// O segundo payload repete o conteúdo do primeiro, então depende do histórico do stream
+	content := strings.Repeat("localidade ", 50)
+	hello := `{"op":10,"d":{"heartbeat_interval":41250}}`
+	dispatch := `{"op":0,"s":1,"t":"MESSAGE_CREATE","d":{"id":"1","content":"` + content + `"}}`
+	again := `{"op":0,"s":2,"t":"MESSAGE_CREATE","d":{"id":"2","content":"` + content + `"}}`
+
+	messages := zlibStreamFixture(t, hello, dispatch, again)
+	if !bytes.HasSuffix(messages[0], zlibSuffix) {
+		t.Fatal("fixture sem o sufixo Z_SYNC_FLUSH")
+	}
+
+	// O primeiro payload chega dividido em três frames e o segundo em dois;
+	// só o último frame de cada payload termina com o sufixo
+	first, second := messages[0], messages[1]
+	frames := [][]byte{
+		first[:1], first[1 : len(first)/2], first[len(first)/2:],
+		second[:len(second)-2], second[len(second)-2:],
+		messages[2],
+	}
+
+	conn := NewZlibStreamConn(&fakeMessageConn{frames: frames})
+
+	var payload GatewayPayload
+	if err := conn.ReadJSON(&payload); err != nil {
+		t.Fatalf("ReadJSON(hello): %v", err)
+	}
+	if payload.Op != OpHello || string(payload.D) != `{"heartbeat_interval":41250}` {
+		t.Errorf("hello = op %d, d %s", payload.Op, payload.D)
+	}
+
+	for seq, id := range []string{"1", "2"} {
+		var payload GatewayPayload
+		if err := conn.ReadJSON(&payload); err != nil {
+			t.Fatalf("ReadJSON(dispatch %d): %v", seq+1, err)
+		}
+		if payload.S == nil || *payload.S != seq+1 {
+			t.Errorf("dispatch %d com sequência %v", seq+1, payload.S)
+		}
+
+		var message MessageCreate
+		if err := json.Unmarshal(payload.D, &message); err != nil {
+			t.Fatal(err)
+		}
+		if message.ID != id || message.Content != content {
+			t.Errorf("dispatch %d = id %q, conteúdo com %d bytes", seq+1, message.ID, len(message.Content))
+		}
+	}
+
+	if err := conn.ReadJSON(&payload); err != io.EOF {
+		t.Errorf("leitura depois do fim = %v, esperado io.EOF", err)
+	}
+}
+
+func TestZlibStreamConnRejectsInvalidHeader(t *testing.T) {
+	conn := NewZlibStreamConn(&fakeMessageConn{frames: [][]byte{{0x00, 0x01, 0x00, 0x00, 0xff, 0xff}}})
+
+	var payload GatewayPayload
+	if err := conn.ReadJSON(&payload); err == nil {
+		t.Fatal("ReadJSON aceitou um stream sem cabeçalho zlib")
+	}
+}
@@ -0,0 +1,65 @@
+package discord
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Evento aguardando a entrega aos handlers do EventBus.
+type queuedEvent struct {
+	eventType string
+	data      json.RawMessage
+	event     any // Evento já decodificado, quando houver
+}
+
+// eventQueue entrega os eventos ao EventBus um de cada vez, na ordem em que chegaram do gateway.
+// A goroutine de leitura só enfileira e volta ao ReadJSON; uma única goroutine de entrega roda
+// enquanto houver eventos na fila, então os handlers nunca recebem dois eventos ao mesmo tempo.
+type eventQueue struct {
+	mu      sync.Mutex
+	pending []queuedEvent
+	running bool // Indica que a goroutine de entrega está ativa
+}
+
+// Enfileira o evento; a entrega é feita por deliver, que roda até a fila esvaziar.
+func (q *eventQueue) push(event queuedEvent, deliver func(queuedEvent)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending = append(q.pending, event)
+	if q.running {
+		return
+	}
+	q.running = true
+
+	go func() {
+		for {
+			q.mu.Lock()
+			if len(q.pending) == 0 {
+				q.running = false
+				q.pending = nil
+				q.mu.Unlock()
+				return
+			}
+			next := q.pending[0]
+			q.pending[0] = queuedEvent{}
+			q.pending = q.pending[1:]
+			q.mu.Unlock()
+
+			deliver(next)
+		}
+	}()
+}
+
+// Coloca o evento na fila do EventBus. Cada evento enfileirado conta como um dispatch em andamento
+// até ser entregue, então o desligamento espera a fila esvaziar.
+func (dc *DiscordClient) queueEvent(eventType string, data json.RawMessage, event any) {
+	if !dc.beginDispatch() {
+		return
+	}
+
+	dc.events.push(queuedEvent{eventType: eventType, data: data, event: event}, func(e queuedEvent) {
+		defer dc.endDispatch()
+		dc.EventBus.dispatch(dc.eventContext(), e.eventType, e.data, e.event)
+	})
+}
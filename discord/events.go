@@ -0,0 +1,286 @@
+package discord
+
+import (
+	"bot-map/interaction"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Nomes dos eventos de dispatch (campo t do payload).
+const (
+	EventReady             = "READY"
+	EventResumed           = "RESUMED"
+	EventGuildCreate       = "GUILD_CREATE"
+	EventGuildUpdate       = "GUILD_UPDATE"
+	EventGuildDelete       = "GUILD_DELETE"
+	EventGuildMemberAdd    = "GUILD_MEMBER_ADD"
+	EventGuildMemberRemove = "GUILD_MEMBER_REMOVE"
+	EventMessageCreate     = "MESSAGE_CREATE"
+	EventMessageUpdate     = "MESSAGE_UPDATE"
+	EventMessageDelete     = "MESSAGE_DELETE"
+	EventInteractionCreate = "INTERACTION_CREATE"
+)
+
+// User representa um usuário do Discord.
+type User = interaction.User
+
+// Guilda que ainda não foi carregada (enviada no READY e no GUILD_DELETE).
+type UnavailableGuild struct {
+	ID          string `json:"id"`
+	Unavailable bool   `json:"unavailable"`
+}
+
+// Ready é enviado após o IDENTIFY, com os dados da sessão e as guildas do bot.
+type Ready struct {
+	Version          int                `json:"v"`
+	User             User               `json:"user"`
+	Guilds           []UnavailableGuild `json:"guilds"`
+	SessionID        string             `json:"session_id"`
+	ResumeGatewayURL string             `json:"resume_gateway_url"`
+	Shard            []int              `json:"shard"`
+	Application      struct {
+		ID string `json:"id"`
+	} `json:"application"`
+}
+
+// Resumed é enviado quando um RESUME termina de reenviar os eventos perdidos.
+type Resumed struct{}
+
+// Guild representa os dados de uma guilda.
+type Guild struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Icon        string    `json:"icon"`
+	OwnerID     string    `json:"owner_id"`
+	MemberCount int       `json:"member_count"`
+	JoinedAt    time.Time `json:"joined_at"`
+	Unavailable bool      `json:"unavailable"`
+}
+
+// GuildCreate é enviado quando uma guilda fica disponível ou o bot entra nela.
+type GuildCreate struct {
+	Guild
+}
+
+// GuildUpdate é enviado quando os dados de uma guilda mudam.
+type GuildUpdate struct {
+	Guild
+}
+
+// GuildDelete é enviado quando uma guilda fica indisponível ou o bot sai dela.
+type GuildDelete struct {
+	UnavailableGuild
+}
+
+// Membro de uma guilda, como enviado nos eventos de membros.
+type GuildMember struct {
+	GuildID  string    `json:"guild_id"`
+	User     User      `json:"user"`
+	Nick     string    `json:"nick"`
+	Roles    []string  `json:"roles"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// GuildMemberAdd é enviado quando alguém entra em uma guilda.
+type GuildMemberAdd struct {
+	GuildMember
+}
+
+// GuildMemberRemove é enviado quando alguém sai de uma guilda.
+type GuildMemberRemove struct {
+	GuildID string `json:"guild_id"`
+	User    User   `json:"user"`
+}
+
+// Message representa uma mensagem enviada em um canal.
+type Message struct {
+	ID              string    `json:"id"`
+	ChannelID       string    `json:"channel_id"`
+	GuildID         string    `json:"guild_id"`
+	Author          User      `json:"author"`
+	Content         string    `json:"content"`
+	Timestamp       time.Time `json:"timestamp"`
+	EditedTimestamp time.Time `json:"edited_timestamp"`
+	Mentions        []User    `json:"mentions"`
+}
+
+// MessageCreate é enviado quando uma mensagem é criada.
+type MessageCreate struct {
+	Message
+}
+
+// MessageUpdate é enviado quando uma mensagem é editada.
+type MessageUpdate struct {
+	Message
+}
+
+// MessageDelete é enviado quando uma mensagem é apagada.
+type MessageDelete struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+	GuildID   string `json:"guild_id"`
+}
+
+// InteractionCreate é enviado quando um usuário usa um comando, componente ou autocomplete.
+type InteractionCreate struct {
+	interaction.Interaction
+}
+
+// Handlers tipados de um evento. Os dados são decodificados uma única vez por evento e o mesmo
+// valor é entregue a todos os handlers, que por isso não devem alterá-lo.
+type eventHandlers struct {
+	decode   func(data json.RawMessage) (any, error) // Decodifica os dados para o tipo do evento
+	handlers []func(ctx context.Context, event any)
+}
+
+// EventBus distribui os eventos de dispatch do gateway para os handlers registrados.
+// Cada evento pode ter vários handlers; eles são chamados na ordem de registro.
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers map[string]*eventHandlers
+	raw      []func(ctx context.Context, eventType string, data json.RawMessage)
+	onError  func(eventType string, err error)
+}
+
+// Cria um EventBus vazio.
+func NewEventBus() *EventBus {
+	return &EventBus{handlers: make(map[string]*eventHandlers)}
+}
+
+// Registra um handler tipado, que recebe os dados do evento já decodificados para T.
+func addHandler[T any](bus *EventBus, eventType string, handler func(ctx context.Context, event *T)) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	entry, ok := bus.handlers[eventType]
+	if !ok {
+		entry = &eventHandlers{
+			decode: func(data json.RawMessage) (any, error) {
+				var event T
+				if err := json.Unmarshal(data, &event); err != nil {
+					return nil, err
+				}
+				return &event, nil
+			},
+		}
+		bus.handlers[eventType] = entry
+	}
+
+	entry.handlers = append(entry.handlers, func(ctx context.Context, event any) {
+		handler(ctx, event.(*T))
+	})
+}
+
+// OnReady registra um handler para o evento READY.
+func (bus *EventBus) OnReady(handler func(ctx context.Context, event *Ready)) {
+	addHandler(bus, EventReady, handler)
+}
+
+// OnResumed registra um handler para o evento RESUMED.
+func (bus *EventBus) OnResumed(handler func(ctx context.Context, event *Resumed)) {
+	addHandler(bus, EventResumed, handler)
+}
+
+// OnGuildCreate registra um handler para o evento GUILD_CREATE.
+func (bus *EventBus) OnGuildCreate(handler func(ctx context.Context, event *GuildCreate)) {
+	addHandler(bus, EventGuildCreate, handler)
+}
+
+// OnGuildUpdate registra um handler para o evento GUILD_UPDATE.
+func (bus *EventBus) OnGuildUpdate(handler func(ctx context.Context, event *GuildUpdate)) {
+	addHandler(bus, EventGuildUpdate, handler)
+}
+
+// OnGuildDelete registra um handler para o evento GUILD_DELETE.
+func (bus *EventBus) OnGuildDelete(handler func(ctx context.Context, event *GuildDelete)) {
+	addHandler(bus, EventGuildDelete, handler)
+}
+
+// OnGuildMemberAdd registra um handler para o evento GUILD_MEMBER_ADD.
+func (bus *EventBus) OnGuildMemberAdd(handler func(ctx context.Context, event *GuildMemberAdd)) {
+	addHandler(bus, EventGuildMemberAdd, handler)
+}
+
+// OnGuildMemberRemove registra um handler para o evento GUILD_MEMBER_REMOVE.
+func (bus *EventBus) OnGuildMemberRemove(handler func(ctx context.Context, event *GuildMemberRemove)) {
+	addHandler(bus, EventGuildMemberRemove, handler)
+}
+
+// OnMessageCreate registra um handler para o evento MESSAGE_CREATE.
+func (bus *EventBus) OnMessageCreate(handler func(ctx context.Context, event *MessageCreate)) {
+	addHandler(bus, EventMessageCreate, handler)
+}
+
+// OnMessageUpdate registra um handler para o evento MESSAGE_UPDATE.
+func (bus *EventBus) OnMessageUpdate(handler func(ctx context.Context, event *MessageUpdate)) {
+	addHandler(bus, EventMessageUpdate, handler)
+}
+
+// OnMessageDelete registra um handler para o evento MESSAGE_DELETE.
+func (bus *EventBus) OnMessageDelete(handler func(ctx context.Context, event *MessageDelete)) {
+	addHandler(bus, EventMessageDelete, handler)
+}
+
+// OnInteractionCreate registra um handler para o evento INTERACTION_CREATE.
+func (bus *EventBus) OnInteractionCreate(handler func(ctx context.Context, event *InteractionCreate)) {
+	addHandler(bus, EventInteractionCreate, handler)
+}
+
+// OnEvent registra um handler que recebe todos os eventos, com os dados crus.
+// Útil para eventos que ainda não têm um tipo próprio.
+func (bus *EventBus) OnEvent(handler func(ctx context.Context, eventType string, data json.RawMessage)) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	bus.raw = append(bus.raw, handler)
+}
+
+// OnError define a função chamada quando os dados de um evento não podem ser decodificados.
+func (bus *EventBus) OnError(handler func(eventType string, err error)) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	bus.onError = handler
+}
+
+// Dispatch entrega um evento a todos os handlers registrados para ele.
+func (bus *EventBus) Dispatch(ctx context.Context, eventType string, data json.RawMessage) {
+	bus.dispatch(ctx, eventType, data, nil)
+}
+
+// Entrega o evento aos handlers; event, quando informado, é o evento já decodificado pelo
+// cliente (no tipo registrado para eventType) e evita decodificar os dados de novo.
+func (bus *EventBus) dispatch(ctx context.Context, eventType string, data json.RawMessage, event any) {
+	bus.mu.RLock()
+	var decode func(data json.RawMessage) (any, error)
+	var handlers []func(ctx context.Context, event any)
+	if entry, ok := bus.handlers[eventType]; ok {
+		decode, handlers = entry.decode, entry.handlers
+	}
+	raw := bus.raw
+	onError := bus.onError
+	bus.mu.RUnlock()
+
+	for _, handler := range raw {
+		handler(ctx, eventType, data)
+	}
+
+	if len(handlers) == 0 {
+		return
+	}
+
+	if event == nil {
+		decoded, err := decode(data)
+		if err != nil {
+			if onError != nil {
+				onError(eventType, err)
+			}
+			return
+		}
+		event = decoded
+	}
+
+	for _, handler := range handlers {
+		handler(ctx, event)
+	}
+}
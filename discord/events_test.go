@@ -0,0 +1,59 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestEventBusDecodesOncePerEvent(t *testing.T) {
+	bus := NewEventBus()
+
+	var received []*MessageCreate
+	for range 3 {
+		bus.OnMessageCreate(func(ctx context.Context, event *MessageCreate) {
+			received = append(received, event)
+		})
+	}
+
+	var raw []string
+	bus.OnEvent(func(ctx context.Context, eventType string, data json.RawMessage) {
+		raw = append(raw, eventType)
+	})
+
+	bus.Dispatch(context.Background(), EventMessageCreate, json.RawMessage(`{"id":"1","content":"oi"}`))
+
+	if len(received) != 3 {
+		t.Fatalf("handlers chamados %d vezes, esperado 3", len(received))
+	}
+	for _, event := range received[1:] {
+		if event != received[0] {
+			t.Error("cada handler recebeu uma decodificação diferente do mesmo evento")
+		}
+	}
+	if received[0].Content != "oi" {
+		t.Errorf("Content = %q, esperado oi", received[0].Content)
+	}
+	if len(raw) != 1 || raw[0] != EventMessageCreate {
+		t.Errorf("OnEvent recebeu %v", raw)
+	}
+}
+
+func TestEventBusReportsDecodeErrors(t *testing.T) {
+	bus := NewEventBus()
+
+	called := false
+	bus.OnMessageCreate(func(ctx context.Context, event *MessageCreate) { called = true })
+
+	var errs []string
+	bus.OnError(func(eventType string, err error) { errs = append(errs, eventType) })
+
+	bus.Dispatch(context.Background(), EventMessageCreate, json.RawMessage(`{"id":`))
+
+	if called {
+		t.Error("handler chamado com dados inválidos")
+	}
+	if len(errs) != 1 {
+		t.Errorf("OnError chamado %d vezes, esperado 1", len(errs))
+	}
+}
@@ -1,65 +1,251 @@
 package discord
 
 import (
+	"bot-map/cmd"
+	"bot-map/interaction"
+	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
+	"time"
 )
 
+// Códigos de operação (opcodes) do gateway do Discord.
+const (
+	OpDispatch       = 0  // Evento normal (dispatch)
+	OpHeartbeat      = 1  // Heartbeat
+	OpIdentify       = 2  // Identificação
+	OpResume         = 6  // Retomada de sessão
+	OpReconnect      = 7  // O gateway pede que o cliente reconecte
+	OpInvalidSession = 9  // Sessão inválida
+	OpHello          = 10 // Primeiro payload da conexão
+	OpHeartbeatAck   = 11 // Reconhecimento de heartbeat
+)
+
+// ReconnectError é devolvido pelo tratamento de payloads quando o gateway pede uma nova conexão.
+type ReconnectError struct {
+	Resume bool   // Indica se a sessão atual ainda pode ser retomada
+	Reason string // Motivo da reconexão
+}
+
+func (e *ReconnectError) Error() string {
+	return fmt.Sprintf("reconnect requested: %s (resume: %t)", e.Reason, e.Resume)
+}
+
 // Função responsável por lidar com eventos recebidos do WebSocket do Discord.
+// Retorna assim que a leitura falha ou o gateway pede uma nova conexão; a reconexão fica a cargo de Run.
 func (dc *DiscordClient) HandleEvents() error {
 	for {
 		var payload GatewayPayload
 
 		// Lê um evento do WebSocket e converte para a estrutura GatewayPayload
 		if err := dc.WsConn.ReadJSON(&payload); err != nil {
-			dc.HandleError(err) // Em caso de erro, trata e tenta reconectar
 			return err
 		}
 
-		// Verifica o código de operação (Op Code) recebido no evento
-		switch payload.Op {
-		case 0: // Evento de Dispatch (evento normal)
-			dc.Sequence = *payload.S // Atualiza o número da sequência de eventos recebidos
-
-			// Verifica se o evento recebido é uma interação de comando (slash command)
-			if payload.T != nil && *payload.T == "INTERACTION_CREATE" {
-				data, _ := json.Marshal(payload.D)
-				var interactionEvent map[string]interface{}
-				json.Unmarshal(data, &interactionEvent)
-
-				// Obtém o tipo da interação
-				interactionType := interactionEvent["type"].(float64)
-
-				if interactionType == 4 { // Tipo 4: Autocomplete de comando
-					commandData := interactionEvent["data"].(map[string]interface{})
-					commandName := commandData["name"].(string)
-
-					// Verifica se o comando existe no registro
-					cmdInfo, exists := dc.Registry.GetCommand(commandName)
-					if exists {
-						// Verifica se o comando implementa a interface de autocomplete
-						if autoCmd, ok := cmdInfo.Command.(interface {
-							HandleAutocomplete(map[string]interface{}) error
-						}); ok {
-							autoCmd.HandleAutocomplete(interactionEvent)
-						}
-					}
-				} else if interactionType == 2 { // Tipo 2: Execução normal de um comando
-					commandData := interactionEvent["data"].(map[string]interface{})
-					commandName := commandData["name"].(string)
-
-					// Verifica se o comando existe no registro
-					cmdInfo, exists := dc.Registry.GetCommand(commandName)
-					if exists {
-						// Executa o comando associado
-						cmdInfo.Command.Execute(interactionEvent)
-					}
-				}
-			}
-		case 11: // Evento de reconhecimento de Heartbeat
-			fmt.Println("Heartbeat recognized: ", payload.Op)
+		// Processa o payload; um erro aqui significa que a conexão atual deve ser descartada
+		if err := dc.HandlePayload(payload); err != nil {
+			return err
+		}
+	}
+}
+
+// HandlePayload aplica a máquina de estados do gateway a um único payload recebido.
+func (dc *DiscordClient) HandlePayload(payload GatewayPayload) error {
+	// Verifica o código de operação (Op Code) recebido no evento
+	switch payload.Op {
+	case OpDispatch: // Evento de Dispatch (evento normal)
+		if payload.S != nil {
+			dc.setSequence(*payload.S) // Atualiza o número da sequência de eventos recebidos
+		}
+		dc.handleDispatch(payload)
+	case OpReconnect: // O Discord pede para reconectar; a sessão continua válida
+		dc.logf("gateway requested reconnect")
+		return &ReconnectError{Resume: true, Reason: "op 7"}
+	case OpInvalidSession: // Sessão inválida: o campo d indica se ainda é possível retomar
+		var resumable bool
+		json.Unmarshal(payload.D, &resumable) // Dados inválidos contam como false
+
+		// O Discord exige uma espera aleatória entre 1 e 5 segundos antes de tentar de novo
+		dc.Clock.Sleep(invalidSessionDelay())
+
+		if resumable {
+			dc.logf("invalid session, resuming")
+			return &ReconnectError{Resume: true, Reason: "op 9"}
+		}
+
+		// A nova sessão passa por supervise, que descarta a atual e espera a vez do IDENTIFY
+		dc.logf("invalid session, identifying again")
+		return &ReconnectError{Resume: false, Reason: "op 9"}
+	case OpHeartbeat: // O gateway pede um heartbeat imediato
+		return dc.SendHeartbeat()
+	case OpHeartbeatAck: // Evento de reconhecimento de Heartbeat
+		dc.acknowledgeHeartbeat()
+		dc.logf("Heartbeat recognized, latency: %s", dc.Latency())
+	}
+
+	return nil
+}
+
+// Sorteia o tempo de espera (entre 1 e 5 segundos) exigido após uma sessão inválida.
+func invalidSessionDelay() time.Duration {
+	return time.Second + time.Duration(rand.Int63n(int64(4*time.Second)))
+}
+
+// Trata os eventos de dispatch (op 0): atualiza o estado da sessão, roteia os comandos
+// e coloca o evento na fila de entrega aos handlers registrados no EventBus.
+// Os eventos que o próprio cliente precisa ler (READY e INTERACTION_CREATE) são decodificados
+// uma única vez, e o valor decodificado é repassado aos handlers do EventBus.
+func (dc *DiscordClient) handleDispatch(payload GatewayPayload) {
+	if payload.T == nil {
+		return
+	}
+	eventType := *payload.T
+	data := payload.D
+
+	var event any // Evento já decodificado, quando houver
+	switch eventType {
+	case EventReady: // Guarda os dados da sessão para poder retomá-la após uma queda de conexão
+		var ready Ready
+		if err := json.Unmarshal(data, &ready); err == nil {
+			dc.setSession(ready.SessionID, ready.ResumeGatewayURL)
+			event = &ready
+		}
+		dc.setState(ShardReady)
+	case EventResumed:
+		dc.logf("session resumed %s", dc.SessionID())
+		dc.setState(ShardReady)
+	case EventInteractionCreate:
+		// Interações inválidas não chegam aos comandos, mas continuam indo para o EventBus
+		i, err := interaction.Parse(data)
+		if err != nil {
+			dc.logf("discarding interaction: %v", err)
+			break
+		}
+		event = &InteractionCreate{Interaction: *i}
+
+		// Comandos rodam fora da goroutine de leitura, que volta imediatamente ao ReadJSON:
+		// assim um comando lento não atrasa as interações seguintes nem os ACKs de heartbeat.
+		// Durante o desligamento, novas interações não são mais atendidas.
+		if dc.beginDispatch() {
+			go func() {
+				defer dc.endDispatch()
+				dc.handleInteraction(i)
+			}()
 		}
 	}
+
+	dc.queueEvent(eventType, data, event)
+}
+
+// Roteia uma interação (slash command ou autocomplete) para o comando registrado
+// e entrega a resposta devolvida pelo comando através do Responder.
+func (dc *DiscordClient) handleInteraction(i *interaction.Interaction) {
+
+	// Os comandos recebem no contexto o acesso a edições e follow-ups da própria interação
+	ctx := interaction.WithFollowups(dc.eventContext(), dc.Responder.Followups(i))
+
+	switch i.Type {
+	case interaction.TypeAutocomplete: // Autocomplete de comando
+		response, err := dc.runCommand(ctx, i)
+		if err != nil {
+			// Sem sugestões: o Discord mostra a lista vazia em vez de um erro
+			dc.logf("autocomplete %s failed: %v", i.CommandName(), err)
+			response = interaction.Autocomplete()
+		}
+		dc.respond(ctx, i, response)
+	case interaction.TypeApplicationCommand: // Execução normal de um comando
+		dc.handleCommand(ctx, i)
+	}
+}
+
+// Resultado da execução de um comando.
+type commandResult struct {
+	response *interaction.Response
+	err      error
+}
+
+// Executa um comando respeitando o prazo de 3 segundos do Discord: se o comando ainda estiver
+// rodando perto do prazo, envia uma resposta adiada (tipo 5) e depois edita a resposta original.
+func (dc *DiscordClient) handleCommand(ctx context.Context, i *interaction.Interaction) {
+	results := make(chan commandResult, 1)
+	go func() {
+		response, err := dc.runCommand(ctx, i)
+		results <- commandResult{response, err}
+	}()
+
+	var result commandResult
+	select {
+	case result = <-results:
+		dc.respond(ctx, i, commandResponse(result))
+		return
+	case <-dc.Clock.After(dc.AutoDeferAfter):
+	}
+
+	// O comando passou do tempo: avisa o Discord que a resposta virá depois
+	dc.logf("command %s is taking long, deferring response", i.CommandName())
+	dc.respond(ctx, i, interaction.Deferred(false))
+
+	result = <-results
+	response := commandResponse(result)
+
+	// Depois do adiamento, só mensagens podem ser entregues; um modal, por exemplo, não tem como abrir
+	if response.Type != interaction.ResponseChannelMessage || response.Data == nil {
+		dc.logf("command %s returned response type %d after being deferred", i.CommandName(), response.Type)
+		response = interaction.EphemeralMessage("❌ O comando demorou demais para responder.")
+	}
+
+	// A resposta adiada é pública; uma mensagem efêmera editada nela ficaria visível para todos.
+	// Nesse caso a resposta adiada é apagada e o conteúdo vai como follow-up efêmero.
+	if response.Data.Flags&interaction.FlagEphemeral != 0 {
+		if err := dc.Responder.DeleteOriginal(ctx, i); err != nil {
+			dc.logf("could not delete deferred response of %s: %v", i.CommandName(), err)
+		}
+		if err := dc.Responder.CreateFollowup(ctx, i, response.Data); err != nil {
+			dc.logf("could not send ephemeral followup of %s: %v", i.CommandName(), err)
+		}
+		return
+	}
+
+	if err := dc.Responder.EditOriginal(ctx, i, response.Data); err != nil {
+		dc.logf("could not edit deferred response of %s: %v", i.CommandName(), err)
+	}
+}
+
+// Converte o resultado de um comando na resposta enviada ao usuário; erros viram mensagem efêmera.
+func commandResponse(result commandResult) *interaction.Response {
+	if result.err != nil {
+		return interaction.EphemeralMessage("❌ " + result.err.Error())
+	}
+	if result.response == nil {
+		return interaction.EphemeralMessage("✅")
+	}
+	return result.response
+}
+
+// Entrega uma resposta ao Discord, registrando falhas no log.
+func (dc *DiscordClient) respond(ctx context.Context, i *interaction.Interaction, response *interaction.Response) {
+	if err := dc.Responder.Respond(ctx, i, response); err != nil {
+		dc.logf("could not respond to %s: %v", i.CommandName(), err)
+	}
+}
+
+// Executa o comando (ou subcomando) correspondente à interação, passando pela cadeia de middlewares.
+func (dc *DiscordClient) runCommand(ctx context.Context, i *interaction.Interaction) (*interaction.Response, error) {
+	// Encontra a folha que trata a interação
+	route, err := dc.Registry.Resolve(i)
+	if err != nil {
+		return nil, err
+	}
+
+	// Valida as opções contra as declaradas na folha
+	opts, err := route.Options(i)
+	if err != nil {
+		return nil, err
+	}
+
+	// Executa o comando associado (ou o autocomplete, conforme o tipo da interação)
+	return dc.Registry.Handler(route)(ctx, &cmd.Request{Interaction: i, Route: route, Options: opts})
 }
 
 // Basicamnete monitora os eventos recebidos do WebSocket do Disc
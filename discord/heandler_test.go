@@ -0,0 +1,324 @@
+package discord
+
+import (
+	"bot-map/cmd"
+	"bot-map/config"
+	"bot-map/interaction"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Conexão falsa que guarda os payloads enviados ao gateway.
+type fakeConn struct {
+	mu   sync.Mutex
+	sent []GatewayPayload
+}
+
+func (c *fakeConn) ReadJSON(v interface{}) error { return io.EOF }
+func (c *fakeConn) Close() error                 { return nil }
+
+func (c *fakeConn) WriteJSON(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, v.(GatewayPayload))
+	return nil
+}
+
+func (c *fakeConn) payloads() []GatewayPayload {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]GatewayPayload(nil), c.sent...)
+}
+
+// Relógio falso: o tempo só anda quando o teste manda, Sleep apenas registra a espera
+// e After dispara imediatamente.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sleeps = append(c.sleeps, d)
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.Now()
+	return ch
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) slept() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.sleeps...)
+}
+
+// Cria um cliente já conectado à conexão falsa, com o writer rodando.
+func newTestClient(t *testing.T) (*DiscordClient, *fakeConn, *fakeClock) {
+	t.Helper()
+
+	conn := &fakeConn{}
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	dc := NewDiscordClient(&config.Config{Token: "token"}, cmd.NewCommandRegistry(),
+		WithClock(clock),
+		WithLogger(log.New(io.Discard, "", 0)),
+	)
+
+	dc.WsConn = conn
+	dc.resetHeartbeat()
+	dc.startWriter(conn)
+	t.Cleanup(dc.stopWriter)
+
+	return dc, conn, clock
+}
+
+func payload(t *testing.T, op int, data interface{}) GatewayPayload {
+	t.Helper()
+
+	p, err := NewGatewayPayload(op, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestHandlePayloadReconnect(t *testing.T) {
+	dc, conn, _ := newTestClient(t)
+	dc.setSession("session", "wss://resume.discord.gg")
+
+	err := dc.HandlePayload(payload(t, OpReconnect, nil))
+
+	var reconnect *ReconnectError
+	if !errors.As(err, &reconnect) || !reconnect.Resume {
+		t.Fatalf("erro = %v, esperado ReconnectError com Resume", err)
+	}
+	if dc.SessionID() != "session" {
+		t.Error("op 7 descartou a sessão, que ainda pode ser retomada")
+	}
+	if sent := conn.payloads(); len(sent) != 0 {
+		t.Errorf("op 7 enviou %d payloads, esperado nenhum", len(sent))
+	}
+}
+
+func TestHandlePayloadInvalidSessionResumable(t *testing.T) {
+	dc, conn, clock := newTestClient(t)
+	dc.setSession("session", "wss://resume.discord.gg")
+
+	err := dc.HandlePayload(payload(t, OpInvalidSession, true))
+
+	var reconnect *ReconnectError
+	if !errors.As(err, &reconnect) || !reconnect.Resume {
+		t.Fatalf("erro = %v, esperado ReconnectError com Resume", err)
+	}
+	if dc.SessionID() != "session" {
+		t.Error("op 9 retomável descartou a sessão")
+	}
+	assertInvalidSessionDelay(t, clock)
+	if sent := conn.payloads(); len(sent) != 0 {
+		t.Errorf("op 9 retomável enviou %d payloads, esperado nenhum", len(sent))
+	}
+}
+
+func TestHandlePayloadInvalidSessionNotResumable(t *testing.T) {
+	dc, conn, clock := newTestClient(t)
+	dc.setSession("session", "wss://resume.discord.gg")
+
+	err := dc.HandlePayload(payload(t, OpInvalidSession, false))
+
+	// O IDENTIFY não sai pela conexão atual: supervise descarta a sessão e espera o bucket do shard
+	var reconnect *ReconnectError
+	if !errors.As(err, &reconnect) || reconnect.Resume {
+		t.Fatalf("erro = %v, esperado ReconnectError sem Resume", err)
+	}
+	assertInvalidSessionDelay(t, clock)
+	if sent := conn.payloads(); len(sent) != 0 {
+		t.Errorf("op 9 não retomável enviou %d payloads, esperado nenhum", len(sent))
+	}
+}
+
+func assertInvalidSessionDelay(t *testing.T, clock *fakeClock) {
+	t.Helper()
+
+	sleeps := clock.slept()
+	if len(sleeps) != 1 || sleeps[0] < time.Second || sleeps[0] > 5*time.Second {
+		t.Errorf("esperas = %v, esperado uma entre 1s e 5s", sleeps)
+	}
+}
+
+func TestHandlePayloadHeartbeatRequestAndAck(t *testing.T) {
+	dc, conn, clock := newTestClient(t)
+	dc.setSequence(42)
+
+	// O gateway pede um heartbeat imediato (op 1)
+	if err := dc.HandlePayload(payload(t, OpHeartbeat, nil)); err != nil {
+		t.Fatalf("HandlePayload(op 1): %v", err)
+	}
+
+	sent := conn.payloads()
+	if len(sent) != 1 || sent[0].Op != OpHeartbeat || string(sent[0].D) != "42" {
+		t.Fatalf("payloads enviados = %+v, esperado heartbeat com a sequência 42", sent)
+	}
+	if dc.heartbeatAcknowledged() {
+		t.Error("heartbeat marcado como reconhecido antes do ACK")
+	}
+
+	// O ACK (op 11) chega 150ms depois
+	clock.advance(150 * time.Millisecond)
+	if err := dc.HandlePayload(payload(t, OpHeartbeatAck, nil)); err != nil {
+		t.Fatalf("HandlePayload(op 11): %v", err)
+	}
+
+	if !dc.heartbeatAcknowledged() {
+		t.Error("heartbeat não reconhecido depois do ACK")
+	}
+	if dc.Latency() != 150*time.Millisecond {
+		t.Errorf("latência = %s, esperado 150ms", dc.Latency())
+	}
+	if !dc.LastHeartbeatAck().Equal(clock.Now()) {
+		t.Errorf("último ACK = %s, esperado %s", dc.LastHeartbeatAck(), clock.Now())
+	}
+}
+
+func TestHandlePayloadHeartbeatBeforeFirstDispatch(t *testing.T) {
+	dc, conn, _ := newTestClient(t)
+
+	if err := dc.HandlePayload(payload(t, OpHeartbeat, nil)); err != nil {
+		t.Fatalf("HandlePayload: %v", err)
+	}
+
+	// Sem dispatch recebido, a sequência vai como null
+	sent := conn.payloads()
+	if len(sent) != 1 || string(sent[0].D) != "null" {
+		t.Fatalf("payloads enviados = %+v, esperado heartbeat com null", sent)
+	}
+}
+
+func TestHandlePayloadDeliversEventsInOrder(t *testing.T) {
+	dc, _, _ := newTestClient(t)
+
+	var (
+		mu       sync.Mutex
+		active   int
+		overlaps int
+		ids      []string
+	)
+	dc.EventBus.OnMessageCreate(func(ctx context.Context, event *MessageCreate) {
+		mu.Lock()
+		active++
+		if active > 1 {
+			overlaps++
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		active--
+		ids = append(ids, event.ID)
+		mu.Unlock()
+	})
+
+	var want []string
+	for seq := 1; seq <= 20; seq++ {
+		id := strconv.Itoa(seq)
+		want = append(want, id)
+
+		p := payload(t, OpDispatch, MessageCreate{Message{ID: id}})
+		eventType := EventMessageCreate
+		p.S, p.T = &seq, &eventType
+		if err := dc.HandlePayload(p); err != nil {
+			t.Fatalf("HandlePayload: %v", err)
+		}
+	}
+	dc.inflight.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("eventos entregues fora de ordem: %v", ids)
+	}
+	if overlaps != 0 {
+		t.Errorf("%d eventos entregues enquanto outro ainda estava no handler", overlaps)
+	}
+}
+
+func TestHandleCommandDeferredEphemeralGoesToFollowup(t *testing.T) {
+	dc, _, _ := newTestClient(t)
+
+	// O comando só termina depois que a resposta adiada foi enviada
+	release := make(chan struct{})
+	dc.Registry.RegistryCommand(&cmd.CommandInfo{
+		Name: "lento",
+		Command: cmd.CommandFunc(func(ctx context.Context, i *interaction.Interaction, opts *cmd.Options) (*interaction.Response, error) {
+			<-release
+			return interaction.EphemeralMessage("só para você"), nil
+		}),
+	})
+
+	var (
+		mu       sync.Mutex
+		requests []string
+		bodies   []string
+	)
+	dc.Rest = newTestRestClient(func(req *http.Request) *http.Response {
+		body, _ := io.ReadAll(req.Body)
+
+		mu.Lock()
+		requests = append(requests, req.Method+" "+strings.TrimPrefix(req.URL.Path, "/api"))
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+
+		if strings.HasSuffix(req.URL.Path, "/callback") {
+			close(release)
+		}
+		return emptyResponse(http.Header{})
+	}, dc.Clock)
+	dc.Responder = NewInteractionResponder(dc.Rest)
+
+	// ID gerado agora, para o token da interação ainda valer
+	id := strconv.FormatInt((time.Now().UnixMilli()-1420070400000)<<22, 10)
+	dc.handleInteraction(&interaction.Interaction{
+		ID:            id,
+		ApplicationID: "1",
+		Type:          interaction.TypeApplicationCommand,
+		Token:         "token",
+		Data:          &interaction.ApplicationCommandData{Name: "lento"},
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		"POST /interactions/" + id + "/token/callback",
+		"DELETE /webhooks/1/token/messages/@original",
+		"POST /webhooks/1/token",
+	}
+	if strings.Join(requests, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("requisições = %q, esperado %q", requests, want)
+	}
+	if !strings.Contains(bodies[2], "só para você") || !strings.Contains(bodies[2], `"flags":64`) {
+		t.Errorf("follow-up = %s, esperado o conteúdo com a flag efêmera", bodies[2])
+	}
+}
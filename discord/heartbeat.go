@@ -0,0 +1,142 @@
+package discord
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Código de fechamento usado quando a conexão é considerada zumbi.
+// Qualquer código diferente de 1000 mantém a sessão válida para ser retomada.
+const CloseZombieConnection = 4000
+
+// Erro devolvido quando um heartbeat fica sem ACK até o próximo envio.
+var ErrZombieConnection = errors.New("heartbeat not acknowledged, zombie connection")
+
+// Conexões que sabem enviar um close frame com um código específico.
+type closeCoder interface {
+	CloseWithCode(code int, reason string) error
+}
+
+// Adaptador da conexão do gorilla/websocket que permite fechar com um código específico.
+type gorillaConn struct {
+	*websocket.Conn
+}
+
+// Envia o close frame com o código informado e fecha a conexão.
+func (c *gorillaConn) CloseWithCode(code int, reason string) error {
+	message := websocket.FormatCloseMessage(code, reason)
+	c.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+	return c.Conn.Close()
+}
+
+// Função para enviar heartbeats periodicamente para manter a conexão ativa.
+// Se um heartbeat não recebe ACK antes do próximo, a conexão é fechada para forçar a reconexão.
+// O loop termina quando o canal stop é fechado.
+func (dc *DiscordClient) StartHeartbeating(stop <-chan struct{}) error {
+	// O primeiro heartbeat é enviado após intervalo * jitter, como pede o Discord
+	select {
+	case <-stop:
+		return nil
+	case <-dc.Clock.After(time.Duration(float64(dc.Heartbeat) * rand.Float64())):
+	}
+
+	if err := dc.SendHeartbeat(); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-dc.Clock.After(dc.Heartbeat):
+		}
+
+		// Nenhum ACK desde o último heartbeat: a conexão está zumbi
+		if !dc.heartbeatAcknowledged() {
+			dc.logf("heartbeat not acknowledged, closing zombie connection")
+			dc.closeConn(CloseZombieConnection, "heartbeat not acknowledged")
+			return ErrZombieConnection
+		}
+
+		if err := dc.SendHeartbeat(); err != nil {
+			return err
+		}
+	}
+}
+
+// SendHeartbeat envia um heartbeat (op 1) com o último número de sequência recebido.
+func (dc *DiscordClient) SendHeartbeat() error {
+	// Antes do primeiro dispatch o Discord espera null no lugar da sequência
+	var sequence interface{}
+	if seq := dc.Sequence(); seq > 0 {
+		sequence = seq
+	}
+
+	heartbeat, err := NewGatewayPayload(OpHeartbeat, sequence)
+	if err != nil {
+		return err
+	}
+
+	dc.heartbeatMu.Lock()
+	dc.heartbeatAcked = false
+	dc.lastHeartbeatAt = dc.Clock.Now()
+	dc.heartbeatMu.Unlock()
+
+	// Heartbeats têm prioridade na fila de envio para não serem atrasados por outros payloads
+	if err := dc.sendPriority(heartbeat); err != nil {
+		return err
+	}
+
+	dc.logf("Heartbeat sent")
+	return nil
+}
+
+// Latency retorna o tempo de ida e volta do último heartbeat reconhecido.
+func (dc *DiscordClient) Latency() time.Duration {
+	dc.heartbeatMu.Lock()
+	defer dc.heartbeatMu.Unlock()
+	return dc.latency
+}
+
+// LastHeartbeatAck retorna o momento em que o último ACK de heartbeat foi recebido.
+func (dc *DiscordClient) LastHeartbeatAck() time.Time {
+	dc.heartbeatMu.Lock()
+	defer dc.heartbeatMu.Unlock()
+	return dc.lastHeartbeatAck
+}
+
+// Registra o recebimento de um ACK (op 11) e calcula a latência.
+func (dc *DiscordClient) acknowledgeHeartbeat() {
+	dc.heartbeatMu.Lock()
+	defer dc.heartbeatMu.Unlock()
+
+	dc.heartbeatAcked = true
+	dc.lastHeartbeatAck = dc.Clock.Now()
+	dc.latency = dc.lastHeartbeatAck.Sub(dc.lastHeartbeatAt)
+}
+
+// Verifica se o último heartbeat enviado já foi reconhecido.
+func (dc *DiscordClient) heartbeatAcknowledged() bool {
+	dc.heartbeatMu.Lock()
+	defer dc.heartbeatMu.Unlock()
+	return dc.heartbeatAcked
+}
+
+// Reinicia o acompanhamento de heartbeats para uma nova conexão.
+func (dc *DiscordClient) resetHeartbeat() {
+	dc.heartbeatMu.Lock()
+	defer dc.heartbeatMu.Unlock()
+	dc.heartbeatAcked = true
+	dc.lastHeartbeatAt = time.Time{}
+}
+
+// Fecha a conexão atual com o código informado, quando a conexão suporta isso.
+func (dc *DiscordClient) closeConn(code int, reason string) error {
+	if conn, ok := dc.WsConn.(closeCoder); ok {
+		return conn.CloseWithCode(code, reason)
+	}
+	return dc.WsConn.Close()
+}
@@ -0,0 +1,66 @@
+package discord
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Conexão falsa que registra o código usado para fechá-la.
+type closeCodeConn struct {
+	fakeConn
+	mu    sync.Mutex
+	codes []int
+}
+
+func (c *closeCodeConn) CloseWithCode(code int, reason string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.codes = append(c.codes, code)
+	return nil
+}
+
+func TestHeartbeatClosesZombieConnection(t *testing.T) {
+	dc, _, _ := newTestClient(t)
+	conn := &closeCodeConn{}
+	dc.WsConn = conn
+	dc.startWriter(conn)
+	dc.Heartbeat = time.Second
+
+	// O relógio falso dispara as esperas na hora: o segundo heartbeat encontra o primeiro sem ACK
+	if err := dc.StartHeartbeating(make(chan struct{})); !errors.Is(err, ErrZombieConnection) {
+		t.Fatalf("StartHeartbeating = %v, esperado ErrZombieConnection", err)
+	}
+
+	if sent := conn.payloads(); len(sent) != 1 || sent[0].Op != OpHeartbeat {
+		t.Errorf("payloads enviados = %+v, esperado um heartbeat", sent)
+	}
+
+	// Um código diferente de 1000 mantém a sessão para ser retomada
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if len(conn.codes) != 1 || conn.codes[0] != CloseZombieConnection {
+		t.Errorf("conexão fechada com %v, esperado [%d]", conn.codes, CloseZombieConnection)
+	}
+}
+
+func TestHeartbeatStopsWhenAsked(t *testing.T) {
+	dc, _, _ := newTestClient(t)
+	dc.Clock = newSupervisorClock() // O intervalo de uma hora nunca dispara
+	dc.Heartbeat = time.Hour
+
+	stop := make(chan struct{})
+	result := make(chan error, 1)
+	go func() { result <- dc.StartHeartbeating(stop) }()
+
+	close(stop)
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("StartHeartbeating = %v, esperado nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("StartHeartbeating não parou depois do stop")
+	}
+}
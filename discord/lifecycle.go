@@ -0,0 +1,128 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Tempo padrão que o desligamento espera pelos comandos em andamento.
+const defaultShutdownTimeout = 10 * time.Second
+
+// Run conecta ao gateway e mantém o bot rodando até que o contexto seja cancelado,
+// Close seja chamado ou o gateway feche com um código fatal.
+// No desligamento, deixa de aceitar novos dispatches, espera os comandos em andamento
+// (até ShutdownTimeout), executa os hooks de desligamento e fecha o gateway com código 1000.
+func (dc *DiscordClient) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	dc.mu.Lock()
+	dc.cancel = cancel
+	dc.done = done
+	dc.closing = false
+	// Os handlers não são cancelados junto com Run: o desligamento espera que eles terminem
+	dc.eventCtx = context.WithoutCancel(ctx)
+	dc.mu.Unlock()
+
+	err := dc.supervise(ctx)
+
+	// O desligamento pode chegar durante o backoff, a espera do IDENTIFY ou a conexão, fora de
+	// runSession: os comandos em andamento são esperados de qualquer forma antes dos hooks
+	dc.drain()
+
+	if ctx.Err() != nil {
+		dc.logf("shutting down")
+		err = dc.runShutdownHooks()
+	}
+
+	dc.mu.Lock()
+	dc.runErr = err
+	dc.mu.Unlock()
+
+	return err
+}
+
+// Close encerra o cliente de forma graciosa e espera Run retornar.
+func (dc *DiscordClient) Close() error {
+	dc.mu.Lock()
+	cancel, done := dc.cancel, dc.done
+	dc.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+	<-done
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return dc.runErr
+}
+
+// Retorna o contexto entregue aos handlers de eventos.
+func (dc *DiscordClient) eventContext() context.Context {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	if dc.eventCtx == nil {
+		return context.Background()
+	}
+	return dc.eventCtx
+}
+
+// Marca o início de um dispatch; retorna false se o cliente já está desligando.
+func (dc *DiscordClient) beginDispatch() bool {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	if dc.closing {
+		return false
+	}
+	dc.inflight.Add(1)
+	return true
+}
+
+// Marca o fim de um dispatch iniciado com beginDispatch.
+func (dc *DiscordClient) endDispatch() {
+	dc.inflight.Done()
+}
+
+// Para de aceitar dispatches e espera os que estão em andamento, até ShutdownTimeout.
+func (dc *DiscordClient) drain() {
+	dc.mu.Lock()
+	dc.closing = true
+	dc.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		dc.inflight.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-dc.Clock.After(dc.ShutdownTimeout):
+		dc.logf("shutdown timeout reached with commands still running")
+	}
+}
+
+// Executa os hooks de desligamento (ex.: gravar o armazenamento em disco) com o prazo de ShutdownTimeout.
+func (dc *DiscordClient) runShutdownHooks() error {
+	ctx, cancel := context.WithTimeout(context.Background(), dc.ShutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, hook := range dc.shutdownHooks {
+		if err := hook(ctx); err != nil {
+			dc.logf("shutdown hook failed: %v", err)
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,55 @@
+package discord
+
+import (
+	"bot-map/cmd"
+	"bot-map/config"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunDrainsWhenStoppedDuringBackoff(t *testing.T) {
+	dialed := make(chan struct{}, 1)
+	var finished, finishedBeforeHook atomic.Bool
+
+	var dc *DiscordClient
+	dc = NewDiscordClient(&config.Config{Token: "token"}, cmd.NewCommandRegistry(),
+		WithLogger(log.New(io.Discard, "", 0)),
+		WithDialer(func(gatewayURL string) (WebsocketConn, error) {
+			select {
+			case dialed <- struct{}{}:
+			default:
+			}
+			return nil, errors.New("dial failed")
+		}),
+		WithShutdownHook(func(ctx context.Context) error {
+			finishedBeforeHook.Store(finished.Load())
+			return nil
+		}),
+	)
+
+	// Um comando ainda está rodando quando o desligamento chega, durante o backoff da reconexão
+	if !dc.beginDispatch() {
+		t.Fatal("beginDispatch recusado antes de Run")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-dialed
+		cancel()
+		time.Sleep(50 * time.Millisecond)
+		finished.Store(true)
+		dc.endDispatch()
+	}()
+
+	if err := dc.Run(ctx); !errors.Is(err, context.Canceled) && err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !finishedBeforeHook.Load() {
+		t.Error("hooks de desligamento rodaram com um comando em andamento")
+	}
+}
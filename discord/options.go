@@ -0,0 +1,125 @@
+package discord
+
+import (
+	"bot-map/shared"
+	"context"
+	"log"
+	"os"
+	"time"
+)
+
+// Intents do gateway (quais eventos o bot quer receber).
+const (
+	IntentGuilds         = 1 << 0  // Eventos de guildas
+	IntentGuildMessages  = 1 << 9  // Mensagens enviadas nas guildas
+	IntentMessageContent = 1 << 15 // Conteúdo das mensagens
+
+	// Intents usados quando nenhum é informado
+	DefaultIntents = IntentGuilds | IntentGuildMessages | IntentMessageContent
+)
+
+// Clock abstrai o tempo para que heartbeats, backoff e limites de envio possam ser controlados em testes.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+}
+
+// Relógio real, baseado no pacote time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Option configura um DiscordClient criado por NewDiscordClient.
+type Option func(*DiscordClient)
+
+// WithHTTPClient define o cliente HTTP usado nas requisições REST.
+func WithHTTPClient(client shared.HTTPClient) Option {
+	return func(dc *DiscordClient) {
+		dc.Client = client
+	}
+}
+
+// WithRestClient define o cliente REST, permitindo que vários clientes (ex.: shards)
+// compartilhem o mesmo estado de rate limit.
+func WithRestClient(rest *RestClient) Option {
+	return func(dc *DiscordClient) {
+		dc.Rest = rest
+	}
+}
+
+// WithDialer define a função que abre a conexão WebSocket com o gateway.
+func WithDialer(dial func(gatewayURL string) (WebsocketConn, error)) Option {
+	return func(dc *DiscordClient) {
+		dc.Dial = dial
+	}
+}
+
+// WithLogger define o logger do cliente.
+func WithLogger(logger *log.Logger) Option {
+	return func(dc *DiscordClient) {
+		dc.Logger = logger
+	}
+}
+
+// WithIntents define os intents enviados no IDENTIFY.
+func WithIntents(intents int) Option {
+	return func(dc *DiscordClient) {
+		dc.Intents = intents
+	}
+}
+
+// WithClock define o relógio usado para heartbeats, backoff e limites de envio.
+func WithClock(clock Clock) Option {
+	return func(dc *DiscordClient) {
+		dc.Clock = clock
+	}
+}
+
+// WithEventBus define o EventBus do cliente, permitindo que vários clientes (ex.: shards)
+// compartilhem os mesmos handlers.
+func WithEventBus(bus *EventBus) Option {
+	return func(dc *DiscordClient) {
+		dc.EventBus = bus
+	}
+}
+
+// WithShutdownTimeout define quanto tempo o desligamento espera pelos comandos em andamento.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(dc *DiscordClient) {
+		dc.ShutdownTimeout = timeout
+	}
+}
+
+// WithAutoDeferAfter define após quanto tempo um comando ainda em execução recebe uma resposta adiada.
+func WithAutoDeferAfter(d time.Duration) Option {
+	return func(dc *DiscordClient) {
+		dc.AutoDeferAfter = d
+	}
+}
+
+// WithShutdownHook adiciona uma função executada no desligamento, depois que os comandos
+// em andamento terminam (ex.: gravar o armazenamento em disco).
+func WithShutdownHook(hook func(ctx context.Context) error) Option {
+	return func(dc *DiscordClient) {
+		dc.shutdownHooks = append(dc.shutdownHooks, hook)
+	}
+}
+
+// Define o shard atendido pelo cliente (usado pelo ShardManager).
+// Os hooks de desligamento ficam com o ShardManager, para não rodarem uma vez por shard.
+func withShard(id, count int, identifyGate func(ctx context.Context, shardID int) error) Option {
+	return func(dc *DiscordClient) {
+		dc.ShardID = id
+		dc.ShardCount = count
+		dc.identifyGate = identifyGate
+		dc.shutdownHooks = nil
+	}
+}
+
+// Logger padrão: mesma saída que o bot sempre usou (stdout).
+func defaultLogger() *log.Logger {
+	return log.New(os.Stdout, "", log.LstdFlags)
+}
@@ -0,0 +1,108 @@
+package discord
+
+import (
+	"bot-map/interaction"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// O Discord espera a primeira resposta em até 3 segundos; a margem cobre a latência da requisição.
+const defaultAutoDeferAfter = 2 * time.Second
+
+// Erro devolvido ao editar ou responder uma interação cujo token já expirou.
+var ErrInteractionExpired = errors.New("interaction token expired (valid for 15 minutes)")
+
+// InteractionResponder entrega ao Discord as respostas devolvidas pelos comandos.
+// Concentra a montagem da URL de callback, a autenticação e a verificação do status.
+type InteractionResponder struct {
+	Rest *RestClient // Cliente REST usado para enviar as respostas
+}
+
+// Cria um InteractionResponder.
+func NewInteractionResponder(rest *RestClient) *InteractionResponder {
+	return &InteractionResponder{
+		Rest: rest,
+	}
+}
+
+// Respond envia a resposta de uma interação (POST /interactions/{id}/{token}/callback).
+func (r *InteractionResponder) Respond(ctx context.Context, i *interaction.Interaction, response *interaction.Response) error {
+	path := fmt.Sprintf("/interactions/%s/%s/callback", i.ID, i.Token)
+	return r.do(ctx, "POST", path, response)
+}
+
+// EditOriginal edita a resposta original (PATCH /webhooks/{app}/{token}/messages/@original).
+// É assim que uma resposta adiada (tipo 5) recebe o seu conteúdo.
+func (r *InteractionResponder) EditOriginal(ctx context.Context, i *interaction.Interaction, data *interaction.ResponseData) error {
+	if err := r.checkToken(i); err != nil {
+		return err
+	}
+	return r.do(ctx, "PATCH", r.webhookURL(i)+"/messages/@original", data)
+}
+
+// DeleteOriginal apaga a resposta original (DELETE /webhooks/{app}/{token}/messages/@original).
+func (r *InteractionResponder) DeleteOriginal(ctx context.Context, i *interaction.Interaction) error {
+	if err := r.checkToken(i); err != nil {
+		return err
+	}
+	return r.do(ctx, "DELETE", r.webhookURL(i)+"/messages/@original", nil)
+}
+
+// CreateFollowup envia uma mensagem adicional na interação (POST /webhooks/{app}/{token}).
+func (r *InteractionResponder) CreateFollowup(ctx context.Context, i *interaction.Interaction, data *interaction.ResponseData) error {
+	if err := r.checkToken(i); err != nil {
+		return err
+	}
+	return r.do(ctx, "POST", r.webhookURL(i), data)
+}
+
+// Followups retorna o acesso às mensagens de acompanhamento de uma interação, para uso pelos comandos.
+func (r *InteractionResponder) Followups(i *interaction.Interaction) interaction.Followups {
+	return &interactionFollowups{responder: r, interaction: i}
+}
+
+// Monta o caminho do webhook da interação.
+func (r *InteractionResponder) webhookURL(i *interaction.Interaction) string {
+	applicationID := i.ApplicationID
+	if applicationID == "" {
+		applicationID = r.Rest.Config.ApplicationID
+	}
+	return fmt.Sprintf("/webhooks/%s/%s", applicationID, i.Token)
+}
+
+// O token de uma interação vale 15 minutos; depois disso o Discord recusa edições e follow-ups.
+func (r *InteractionResponder) checkToken(i *interaction.Interaction) error {
+	if i.Expired(time.Now()) {
+		return fmt.Errorf("%w: interaction %s created at %s", ErrInteractionExpired, i.ID, i.CreatedAt().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Executa a requisição pelo cliente REST.
+func (r *InteractionResponder) do(ctx context.Context, method, path string, body interface{}) error {
+	if err := r.Rest.Do(ctx, method, path, body, nil); err != nil {
+		// O caminho contém o token da interação, então não entra na mensagem de erro
+		return fmt.Errorf("interaction %s failed: %w", method, err)
+	}
+	return nil
+}
+
+// Implementação de interaction.Followups presa a uma interação.
+type interactionFollowups struct {
+	responder   *InteractionResponder
+	interaction *interaction.Interaction
+}
+
+func (f *interactionFollowups) EditOriginal(ctx context.Context, data *interaction.ResponseData) error {
+	return f.responder.EditOriginal(ctx, f.interaction, data)
+}
+
+func (f *interactionFollowups) DeleteOriginal(ctx context.Context) error {
+	return f.responder.DeleteOriginal(ctx, f.interaction)
+}
+
+func (f *interactionFollowups) Send(ctx context.Context, data *interaction.ResponseData) error {
+	return f.responder.CreateFollowup(ctx, f.interaction, data)
+}
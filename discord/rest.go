@@ -0,0 +1,460 @@
+package discord
+
+import (
+	"bot-map/config"
+	"bot-map/shared"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limites do REST do Discord.
+const (
+	restGlobalLimit  = 50          // Requisições por segundo permitidas no total
+	restGlobalWindow = time.Second // Janela do limite global
+	restMaxRetries   = 3           // Tentativas extras após receber 429
+	restSweepEvery   = time.Minute // Intervalo entre limpezas dos buckets expirados
+)
+
+// RestClient faz as chamadas à API REST do Discord respeitando os rate limits.
+// Lê os cabeçalhos X-RateLimit-*, enfileira as requisições de cada bucket, respeita o limite global
+// e repete a requisição após um 429 usando o retry_after informado.
+type RestClient struct {
+	Client shared.HTTPClient // Cliente HTTP de baixo nível
+	Config *config.Config    // URL base e token do bot
+	Clock  Clock             // Relógio usado para as esperas
+	Logger *log.Logger       // Logger das esperas e repetições
+
+	mu          sync.Mutex
+	routes      map[string]*rateBucket // Bucket de cada rota (método + caminho normalizado)
+	buckets     map[string]*rateBucket // Buckets pelo hash informado em X-RateLimit-Bucket
+	globalUntil time.Time              // Fim de um bloqueio global informado pelo Discord
+	global      *gatewayRateLimiter    // Limite global preventivo (50 requisições por segundo)
+	sweptAt     time.Time              // Última limpeza dos buckets expirados
+}
+
+// Estado de um bucket de rate limit. O mutex protege apenas o estado: ele nunca fica preso
+// durante a requisição, então requisições do mesmo bucket com vagas rodam em paralelo.
+type rateBucket struct {
+	mu        sync.Mutex
+	known     bool          // O Discord já informou o limite desta rota (X-RateLimit-Bucket)
+	limit     int           // Requisições permitidas por janela
+	remaining int           // Requisições restantes na janela atual, já descontadas as em andamento
+	window    time.Duration // Duração da última janela informada
+	resetAt   time.Time     // Quando a janela é renovada
+}
+
+// APIError é um erro devolvido pela API do Discord (corpo com code, message e errors).
+type APIError struct {
+	StatusCode int                     // Status HTTP da resposta
+	Code       int                     // Código de erro JSON do Discord (ex.: 50035)
+	Message    string                  // Mensagem do Discord
+	Errors     map[string][]FieldError // Erros por campo, com o caminho achatado (ex.: "options.0.name")
+}
+
+// FieldError é um erro de validação de um campo específico.
+type FieldError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("discord api error %d (status %d): %s", e.Code, e.StatusCode, e.Message)
+
+	// Lista os erros de campo em ordem estável
+	fields := make([]string, 0, len(e.Errors))
+	for field := range e.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		for _, fieldErr := range e.Errors[field] {
+			message += fmt.Sprintf("; %s: %s", field, fieldErr.Message)
+		}
+	}
+	return message
+}
+
+// RateLimitError é devolvido quando a requisição continua recebendo 429 após as tentativas.
+type RateLimitError struct {
+	Route      string        // Rota limitada
+	RetryAfter time.Duration // Tempo pedido pelo Discord antes de tentar de novo
+	Global     bool          // Indica se o limite é global
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited on %s (global: %t), retry after %s", e.Route, e.Global, e.RetryAfter)
+}
+
+// Cria um RestClient.
+func NewRestClient(config *config.Config, client shared.HTTPClient, clock Clock, logger *log.Logger) *RestClient {
+	return &RestClient{
+		Client:  client,
+		Config:  config,
+		Clock:   clock,
+		Logger:  logger,
+		routes:  make(map[string]*rateBucket),
+		buckets: make(map[string]*rateBucket),
+		global:  newGatewayRateLimiter(restGlobalLimit, 0, restGlobalWindow),
+	}
+}
+
+// Do executa uma requisição autenticada para o caminho informado (relativo à URL base).
+// O corpo é enviado como JSON e a resposta, quando result não é nil, é decodificada nele.
+func (r *RestClient) Do(ctx context.Context, method, path string, body, result interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	route := routeKey(method, path)
+
+	for attempt := 0; ; attempt++ {
+		// O bucket é procurado a cada tentativa: depois da primeira resposta a rota pode passar
+		// a usar o bucket compartilhado informado pelo Discord
+		bucket := r.bucketFor(route)
+		if err := r.reserve(ctx, bucket); err != nil {
+			return err
+		}
+
+		if err := r.waitGlobal(ctx); err != nil {
+			return err
+		}
+
+		resp, err := r.send(ctx, method, path, payload)
+		if err != nil {
+			return err
+		}
+
+		r.updateBucket(route, bucket, resp.Header)
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			limited := r.parseRateLimit(route, resp)
+			resp.Body.Close()
+
+			if limited.Global {
+				r.mu.Lock()
+				r.globalUntil = r.Clock.Now().Add(limited.RetryAfter)
+				r.mu.Unlock()
+			}
+
+			if attempt >= restMaxRetries {
+				return limited
+			}
+
+			r.Logger.Printf("rate limited on %s, retrying in %s", limited.Route, limited.RetryAfter)
+			if err := r.wait(ctx, limited.RetryAfter); err != nil {
+				return err
+			}
+			continue
+		}
+
+		return r.handleResponse(resp, result)
+	}
+}
+
+// Monta e envia a requisição HTTP.
+func (r *RestClient) send(ctx context.Context, method, path string, payload []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, r.Config.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bot "+r.Config.Token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return r.Client.Do(req)
+}
+
+// Converte respostas de erro em APIError e decodifica as respostas de sucesso.
+func (r *RestClient) handleResponse(resp *http.Response, result interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return parseAPIError(resp)
+	}
+
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// Retorna o bucket de uma rota, criando um provisório enquanto o Discord não informa o limite.
+func (r *RestClient) bucketFor(route string) *rateBucket {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sweep(r.Clock.Now())
+
+	if bucket, ok := r.routes[route]; ok {
+		return bucket
+	}
+
+	bucket := &rateBucket{}
+	r.routes[route] = bucket
+	return bucket
+}
+
+// Remove as rotas e os buckets cuja janela já terminou. Rotas com token (respostas de interações
+// e webhooks) são usadas por poucos minutos e, sem a limpeza, o mapa cresceria sem limite.
+// Precisa ser chamada com r.mu.
+func (r *RestClient) sweep(now time.Time) {
+	if now.Sub(r.sweptAt) < restSweepEvery {
+		return
+	}
+	r.sweptAt = now
+
+	for route, bucket := range r.routes {
+		if bucket.expired(now) {
+			delete(r.routes, route)
+		}
+	}
+	for hash, bucket := range r.buckets {
+		if bucket.expired(now) {
+			delete(r.buckets, hash)
+		}
+	}
+}
+
+// Indica que a janela do bucket terminou e ele não guarda mais nada que precise ser respeitado.
+func (b *rateBucket) expired(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !now.Before(b.resetAt)
+}
+
+// Reserva uma vaga no bucket, esperando a janela renovar quando as vagas acabaram.
+// Enquanto o Discord não informa o limite da rota, as requisições seguem sem espera.
+func (r *RestClient) reserve(ctx context.Context, bucket *rateBucket) error {
+	for {
+		bucket.mu.Lock()
+		now := r.Clock.Now()
+
+		// A janela terminou: começa uma nova com o limite conhecido, até a resposta trazer os valores reais
+		if bucket.known && !now.Before(bucket.resetAt) {
+			bucket.remaining = bucket.limit
+			bucket.resetAt = now.Add(bucket.window)
+		}
+
+		if !bucket.known || bucket.remaining > 0 {
+			if bucket.known {
+				bucket.remaining--
+			}
+			bucket.mu.Unlock()
+			return nil
+		}
+
+		delay := bucket.resetAt.Sub(now)
+		bucket.mu.Unlock()
+
+		if err := r.wait(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Atualiza o bucket com os cabeçalhos X-RateLimit-* da resposta.
+func (r *RestClient) updateBucket(route string, bucket *rateBucket, header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	hash := header.Get("X-RateLimit-Bucket")
+	if err != nil || hash == "" {
+		// Rota sem rate limit informado: continua sem fila
+		return
+	}
+
+	limit, _ := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	resetAfter, _ := strconv.ParseFloat(header.Get("X-RateLimit-Reset-After"), 64)
+	window := time.Duration(resetAfter * float64(time.Second))
+
+	// Rotas diferentes podem compartilhar o mesmo bucket; as próximas requisições passam a usá-lo
+	r.mu.Lock()
+	if shared, ok := r.buckets[hash]; ok && shared != bucket {
+		r.routes[route] = shared
+		bucket = shared
+	} else {
+		r.buckets[hash] = bucket
+	}
+	r.mu.Unlock()
+
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+
+	bucket.known = true
+	bucket.limit = max(limit, remaining)
+	bucket.window = max(bucket.window, window)
+	bucket.remaining = remaining
+	bucket.resetAt = r.Clock.Now().Add(window)
+}
+
+// Lê o corpo de um 429 (retry_after e global) com fallback para o cabeçalho Retry-After.
+func (r *RestClient) parseRateLimit(route string, resp *http.Response) *RateLimitError {
+	var body struct {
+		RetryAfter float64 `json:"retry_after"`
+		Global     bool    `json:"global"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+
+	if body.RetryAfter == 0 {
+		body.RetryAfter, _ = strconv.ParseFloat(resp.Header.Get("Retry-After"), 64)
+	}
+
+	return &RateLimitError{
+		Route:      redactRoute(route),
+		RetryAfter: time.Duration(body.RetryAfter * float64(time.Second)),
+		Global:     body.Global || resp.Header.Get("X-RateLimit-Global") == "true",
+	}
+}
+
+// Respeita o bloqueio global informado pelo Discord e o limite preventivo de 50 requisições por segundo.
+func (r *RestClient) waitGlobal(ctx context.Context) error {
+	for {
+		r.mu.Lock()
+		now := r.Clock.Now()
+		delay := r.globalUntil.Sub(now)
+		if delay <= 0 {
+			delay = r.global.delay(now, false)
+		}
+		if delay <= 0 {
+			r.global.record(now)
+			r.mu.Unlock()
+			return nil
+		}
+		r.mu.Unlock()
+
+		if err := r.wait(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Espera o tempo informado ou o cancelamento do contexto.
+func (r *RestClient) wait(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-r.Clock.After(d):
+		return nil
+	}
+}
+
+// Converte o corpo de erro do Discord em APIError, achatando a árvore de erros por campo.
+func parseAPIError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	data, _ := io.ReadAll(resp.Body)
+
+	var body struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Errors  json.RawMessage `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		apiErr.Message = strings.TrimSpace(string(data))
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return apiErr
+	}
+
+	apiErr.Code = body.Code
+	apiErr.Message = body.Message
+
+	if len(body.Errors) > 0 {
+		apiErr.Errors = make(map[string][]FieldError)
+		flattenErrors("", body.Errors, apiErr.Errors)
+	}
+
+	return apiErr
+}
+
+// Percorre a árvore "errors" do Discord, onde cada nível pode ter uma lista _errors.
+func flattenErrors(path string, raw json.RawMessage, out map[string][]FieldError) {
+	var node map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &node); err != nil {
+		return
+	}
+
+	for key, value := range node {
+		if key == "_errors" {
+			var fieldErrors []FieldError
+			if json.Unmarshal(value, &fieldErrors) == nil {
+				out[path] = append(out[path], fieldErrors...)
+			}
+			continue
+		}
+
+		child := key
+		if path != "" {
+			child = path + "." + key
+		}
+		flattenErrors(child, value, out)
+	}
+}
+
+// IsAPIError indica se o erro veio da API do Discord com o código informado.
+func IsAPIError(err error, code int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// Normaliza o caminho para identificar a rota: IDs são trocados por marcadores,
+// exceto os parâmetros principais (canal, guilda, webhook e os tokens), que separam os buckets.
+// Cada interação tem o próprio token e, portanto, os próprios limites; as rotas expiradas
+// são removidas por sweep.
+func routeKey(method, path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	parts := strings.Split(path, "/")
+
+	for idx := 1; idx < len(parts); idx++ {
+		previous := parts[idx-1]
+
+		switch {
+		case previous == "channels" || previous == "guilds" || previous == "webhooks":
+			// Parâmetro principal: mantém o ID
+		case idx >= 2 && (parts[idx-2] == "webhooks" || parts[idx-2] == "interactions"):
+			// Token do webhook ou da interação também é parâmetro principal
+		case isSnowflake(parts[idx]):
+			parts[idx] = ":id"
+		}
+	}
+
+	return method + " " + strings.Join(parts, "/")
+}
+
+// Esconde os tokens de webhook e de interação da rota, para que ela possa aparecer em logs e erros.
+func redactRoute(route string) string {
+	parts := strings.Split(route, "/")
+	for idx := 2; idx < len(parts); idx++ {
+		if parts[idx-2] == "webhooks" || parts[idx-2] == "interactions" {
+			parts[idx] = ":token"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Verifica se o trecho do caminho é um ID numérico do Discord.
+func isSnowflake(s string) bool {
+	if len(s) < 15 {
+		return false
+	}
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
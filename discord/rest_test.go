@@ -0,0 +1,166 @@
+package discord
+
+import (
+	"bot-map/config"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Cliente HTTP falso: cada requisição é respondida pela função do teste.
+type fakeHTTPClient func(req *http.Request) *http.Response
+
+func (f fakeHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return f(req), nil
+}
+
+// Relógio falso em que After anda o tempo até o fim da espera e a registra.
+type steppingClock struct {
+	fakeClock
+}
+
+func (c *steppingClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.sleeps = append(c.sleeps, d)
+	c.mu.Unlock()
+	return c.fakeClock.After(0)
+}
+
+func newTestRestClient(client fakeHTTPClient, clock Clock) *RestClient {
+	return NewRestClient(&config.Config{BaseURL: "https://discord.test/api"}, client, clock, log.New(io.Discard, "", 0))
+}
+
+func emptyResponse(header http.Header) *http.Response {
+	return &http.Response{StatusCode: http.StatusNoContent, Header: header, Body: io.NopCloser(strings.NewReader(""))}
+}
+
+func TestRouteKey(t *testing.T) {
+	tests := []struct {
+		method, path, want string
+	}{
+		{"GET", "/channels/123456789012345678/messages/223456789012345678", "GET /channels/123456789012345678/messages/:id"},
+		{"PUT", "/applications/123456789012345678/guilds/323456789012345678/commands", "PUT /applications/:id/guilds/323456789012345678/commands"},
+		{"POST", "/interactions/123456789012345678/token-a/callback", "POST /interactions/:id/token-a/callback"},
+		{"PATCH", "/webhooks/123456789012345678/token-a/messages/@original", "PATCH /webhooks/123456789012345678/token-a/messages/@original"},
+		{"GET", "/gateway/bot?v=10", "GET /gateway/bot"},
+	}
+
+	for _, test := range tests {
+		if got := routeKey(test.method, test.path); got != test.want {
+			t.Errorf("routeKey(%s, %s) = %q, esperado %q", test.method, test.path, got, test.want)
+		}
+	}
+
+	// O token fica fora das rotas mostradas em logs e erros
+	route := routeKey("PATCH", "/webhooks/123456789012345678/token-a/messages/@original")
+	if got := redactRoute(route); got != "PATCH /webhooks/123456789012345678/:token/messages/@original" {
+		t.Errorf("redactRoute(%q) = %q", route, got)
+	}
+}
+
+func TestRestUnknownBucketDoesNotQueue(t *testing.T) {
+	const requests = 5
+
+	// Cada requisição só responde quando todas estão em andamento ao mesmo tempo
+	var arrived sync.WaitGroup
+	arrived.Add(requests)
+	all := make(chan struct{})
+	go func() {
+		arrived.Wait()
+		close(all)
+	}()
+
+	rest := newTestRestClient(func(req *http.Request) *http.Response {
+		arrived.Done()
+		select {
+		case <-all:
+		case <-time.After(2 * time.Second):
+		}
+		return emptyResponse(http.Header{})
+	}, systemClock{})
+
+	errs := make(chan error, requests)
+	for range requests {
+		go func() {
+			errs <- rest.Do(context.Background(), "POST", "/interactions/1/token/callback", nil, nil)
+		}()
+	}
+
+	timeout := time.After(time.Second)
+	for range requests {
+		if err := <-errs; err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+	select {
+	case <-all:
+	case <-timeout:
+		t.Fatal("requisições sem limite conhecido foram enfileiradas")
+	}
+}
+
+func TestRestWaitsForExhaustedBucket(t *testing.T) {
+	clock := &steppingClock{fakeClock{now: time.Unix(1_700_000_000, 0)}}
+	rest := newTestRestClient(func(req *http.Request) *http.Response {
+		return emptyResponse(http.Header{
+			"X-Ratelimit-Bucket":      {"abc"},
+			"X-Ratelimit-Limit":       {"1"},
+			"X-Ratelimit-Remaining":   {"0"},
+			"X-Ratelimit-Reset-After": {"2.5"},
+		})
+	}, clock)
+
+	for attempt := range 2 {
+		if err := rest.Do(context.Background(), "POST", "/channels/1/messages", nil, nil); err != nil {
+			t.Fatalf("Do %d: %v", attempt+1, err)
+		}
+	}
+
+	// A primeira requisição não espera; a segunda espera a janela informada pelo Discord
+	var waits []time.Duration
+	for _, d := range clock.slept() {
+		if d > restGlobalWindow {
+			waits = append(waits, d)
+		}
+	}
+	if fmt.Sprint(waits) != fmt.Sprint([]time.Duration{2500 * time.Millisecond}) {
+		t.Errorf("esperas pelo bucket = %v, esperado [2.5s]", waits)
+	}
+}
+
+func TestRestSweepsExpiredRoutes(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	rest := newTestRestClient(func(req *http.Request) *http.Response {
+		return emptyResponse(http.Header{
+			"X-Ratelimit-Bucket":      {"callback"},
+			"X-Ratelimit-Limit":       {"5"},
+			"X-Ratelimit-Remaining":   {"4"},
+			"X-Ratelimit-Reset-After": {"1"},
+		})
+	}, clock)
+
+	for i := range 3 {
+		path := fmt.Sprintf("/interactions/1/token-%d/callback", i)
+		if err := rest.Do(context.Background(), "POST", path, nil, nil); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+
+	clock.advance(restSweepEvery)
+	if err := rest.Do(context.Background(), "POST", "/interactions/1/token-new/callback", nil, nil); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	rest.mu.Lock()
+	defer rest.mu.Unlock()
+	if len(rest.routes) != 1 || len(rest.buckets) != 1 {
+		t.Errorf("depois da limpeza: %d rotas e %d buckets, esperado 1 e 1", len(rest.routes), len(rest.buckets))
+	}
+}
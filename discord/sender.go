@@ -0,0 +1,182 @@
+package discord
+
+import (
+	"errors"
+	"time"
+)
+
+// Limite de envio do gateway do Discord: 120 payloads a cada 60 segundos por conexão.
+const (
+	gatewaySendLimit    = 120
+	gatewaySendWindow   = 60 * time.Second
+	gatewaySendReserved = 5 // Vagas da janela reservadas para heartbeats
+)
+
+// Erro devolvido quando um payload é enviado sem conexão ativa ou a conexão fecha antes do envio.
+var ErrConnectionClosed = errors.New("gateway connection closed")
+
+// Pedido de envio de um payload, com o canal por onde o resultado da escrita é devolvido.
+type sendRequest struct {
+	payload  GatewayPayload
+	priority bool
+	result   chan error
+}
+
+// gatewayWriter é a única goroutine que escreve em uma conexão com o gateway.
+// O gorilla/websocket não suporta escritas concorrentes, então todo payload passa pela sua fila.
+type gatewayWriter struct {
+	conn     WebsocketConn
+	queue    chan sendRequest // Payloads comuns (IDENTIFY, RESUME, presença...)
+	priority chan sendRequest // Heartbeats, atendidos antes da fila comum
+	stop     chan struct{}
+	limiter  *gatewayRateLimiter
+	clock    Clock
+}
+
+// Cria e inicia o writer da conexão informada, substituindo o anterior.
+func (dc *DiscordClient) startWriter(conn WebsocketConn) {
+	writer := &gatewayWriter{
+		conn:     conn,
+		queue:    make(chan sendRequest),
+		priority: make(chan sendRequest),
+		stop:     make(chan struct{}),
+		limiter:  newGatewayRateLimiter(gatewaySendLimit, gatewaySendReserved, gatewaySendWindow),
+		clock:    dc.Clock,
+	}
+
+	dc.mu.Lock()
+	previous := dc.writer
+	dc.writer = writer
+	dc.mu.Unlock()
+
+	if previous != nil {
+		close(previous.stop)
+	}
+
+	go writer.run()
+}
+
+// Encerra o writer da conexão atual; envios pendentes recebem ErrConnectionClosed.
+func (dc *DiscordClient) stopWriter() {
+	dc.mu.Lock()
+	writer := dc.writer
+	dc.writer = nil
+	dc.mu.Unlock()
+
+	if writer != nil {
+		close(writer.stop)
+	}
+}
+
+// Send coloca um payload na fila de envio da conexão atual e aguarda a escrita.
+func (dc *DiscordClient) Send(payload GatewayPayload) error {
+	return dc.send(payload, false)
+}
+
+// Envia um payload pela fila prioritária (usado para heartbeats).
+func (dc *DiscordClient) sendPriority(payload GatewayPayload) error {
+	return dc.send(payload, true)
+}
+
+func (dc *DiscordClient) send(payload GatewayPayload, priority bool) error {
+	dc.mu.Lock()
+	writer := dc.writer
+	dc.mu.Unlock()
+
+	if writer == nil {
+		return ErrConnectionClosed
+	}
+
+	queue := writer.queue
+	if priority {
+		queue = writer.priority
+	}
+
+	request := sendRequest{payload: payload, priority: priority, result: make(chan error, 1)}
+
+	select {
+	case queue <- request:
+	case <-writer.stop:
+		return ErrConnectionClosed
+	}
+
+	select {
+	case err := <-request.result:
+		return err
+	case <-writer.stop:
+		return ErrConnectionClosed
+	}
+}
+
+// Loop do writer: atende primeiro os heartbeats e respeita o limite de envio do gateway.
+func (w *gatewayWriter) run() {
+	for {
+		var request sendRequest
+
+		select {
+		case <-w.stop:
+			return
+		case request = <-w.priority:
+		default:
+			select {
+			case <-w.stop:
+				return
+			case request = <-w.priority:
+			case request = <-w.queue:
+			}
+		}
+
+		// Aguarda uma vaga na janela de envio, a menos que a conexão seja encerrada
+		if delay := w.limiter.delay(w.clock.Now(), request.priority); delay > 0 {
+			select {
+			case <-w.stop:
+				request.result <- ErrConnectionClosed
+				return
+			case <-w.clock.After(delay):
+			}
+		}
+
+		w.limiter.record(w.clock.Now())
+		request.result <- w.conn.WriteJSON(request.payload)
+	}
+}
+
+// gatewayRateLimiter controla quantos payloads foram enviados dentro da janela deslizante.
+type gatewayRateLimiter struct {
+	limit    int           // Máximo de payloads por janela
+	reserved int           // Vagas que só payloads prioritários podem usar
+	window   time.Duration // Tamanho da janela
+	sent     []time.Time   // Momentos dos envios ainda dentro da janela
+}
+
+func newGatewayRateLimiter(limit, reserved int, window time.Duration) *gatewayRateLimiter {
+	return &gatewayRateLimiter{limit: limit, reserved: reserved, window: window}
+}
+
+// Retorna quanto tempo esperar até poder enviar mais um payload.
+func (l *gatewayRateLimiter) delay(now time.Time, priority bool) time.Duration {
+	// Descarta os envios que já saíram da janela
+	cutoff := now.Add(-l.window)
+	expired := 0
+	for expired < len(l.sent) && !l.sent[expired].After(cutoff) {
+		expired++
+	}
+	l.sent = l.sent[expired:]
+
+	limit := l.limit
+	if !priority {
+		limit -= l.reserved
+	}
+
+	if len(l.sent) < limit {
+		return 0
+	}
+
+	// Espera até que o envio mais antigo que ultrapassa o limite saia da janela
+	return l.sent[len(l.sent)-limit].Add(l.window).Sub(now)
+}
+
+// Registra um envio feito agora.
+func (l *gatewayRateLimiter) record(now time.Time) {
+	l.sent = append(l.sent, now)
+}
@@ -0,0 +1,302 @@
+package discord
+
+import (
+	"bot-map/cmd"
+	"bot-map/config"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Intervalo mínimo entre dois IDENTIFY do mesmo bucket de concorrência.
+const identifyInterval = 5 * time.Second
+
+// ShardState representa o estado da conexão de um shard com o gateway.
+type ShardState string
+
+const (
+	ShardIdle         ShardState = "idle"         // Ainda não iniciou
+	ShardConnecting   ShardState = "connecting"   // Abrindo conexão e enviando IDENTIFY/RESUME
+	ShardReady        ShardState = "ready"        // Recebeu READY ou RESUMED
+	ShardReconnecting ShardState = "reconnecting" // Aguardando para reconectar
+	ShardStopped      ShardState = "stopped"      // Parou por um erro fatal
+)
+
+// ShardStatus é um retrato do estado de um shard em um dado momento.
+type ShardStatus struct {
+	ID               int           // Índice do shard
+	State            ShardState    // Estado da conexão
+	SessionID        string        // Sessão atual do gateway
+	Sequence         int           // Última sequência recebida
+	Latency          time.Duration // Latência do último heartbeat
+	LastHeartbeatAck time.Time     // Momento do último ACK de heartbeat
+}
+
+// Resposta de GET /gateway/bot.
+type GatewayBotResponse struct {
+	URL               string            `json:"url"`                 // URL do gateway
+	Shards            int               `json:"shards"`              // Quantidade de shards recomendada
+	SessionStartLimit SessionStartLimit `json:"session_start_limit"` // Limite de inícios de sessão
+}
+
+// Limite de inícios de sessão (IDENTIFY) informado pelo Discord.
+type SessionStartLimit struct {
+	Total          int `json:"total"`           // Total de inícios permitidos por janela
+	Remaining      int `json:"remaining"`       // Inícios ainda disponíveis
+	ResetAfter     int `json:"reset_after"`     // Milissegundos até o limite ser renovado
+	MaxConcurrency int `json:"max_concurrency"` // Quantos IDENTIFY podem ocorrer a cada 5 segundos
+}
+
+// ShardManager mantém uma conexão com o gateway para cada shard do bot.
+// Os handlers registrados no ShardManager recebem os eventos de todos os shards.
+type ShardManager struct {
+	*EventBus // Handlers compartilhados pelos shards
+
+	Config   *config.Config       // Configurações do bot
+	Registry *cmd.CommandRegistry // Registro de comandos compartilhado pelos shards
+
+	opts   []Option       // Opções repassadas a cada shard
+	client *DiscordClient // Cliente base, usado para as chamadas REST e como referência de logger/relógio
+
+	mu     sync.Mutex
+	shards []*DiscordClient
+	cancel context.CancelFunc // Cancela o contexto de Run
+	done   chan struct{}      // Fechado quando Run retorna
+	runErr error              // Resultado do último Run
+}
+
+// Cria um ShardManager; os shards só são criados quando Run é chamado.
+// As opções são aplicadas a todos os shards.
+func NewShardManager(config *config.Config, registry *cmd.CommandRegistry, opts ...Option) *ShardManager {
+	client := NewDiscordClient(config, registry, opts...)
+
+	return &ShardManager{
+		EventBus: client.EventBus,
+		Config:   config,
+		Registry: registry,
+		opts:     opts,
+		client:   client,
+	}
+}
+
+// GatewayBot consulta GET /gateway/bot para obter a URL, o número de shards recomendado e os limites de sessão.
+func (sm *ShardManager) GatewayBot() (*GatewayBotResponse, error) {
+	var gateway GatewayBotResponse
+	if err := sm.client.Rest.Do(context.Background(), "GET", "/gateway/bot", nil, &gateway); err != nil {
+		return nil, fmt.Errorf("failed to fetch gateway info: %w", err)
+	}
+
+	return &gateway, nil
+}
+
+// Run busca a configuração recomendada de shards e mantém uma conexão por shard até que o
+// contexto seja cancelado, Close seja chamado ou algum shard pare com um erro fatal.
+// Nesse caso os demais shards também são encerrados.
+func (sm *ShardManager) Run(ctx context.Context) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	// Guarda o resultado para Close antes de liberar quem está esperando
+	defer func() {
+		sm.mu.Lock()
+		sm.runErr = err
+		sm.mu.Unlock()
+	}()
+
+	sm.mu.Lock()
+	sm.cancel = cancel
+	sm.done = done
+	sm.mu.Unlock()
+
+	gateway, err := sm.GatewayBot()
+	if err != nil {
+		return err
+	}
+
+	shardCount := max(gateway.Shards, 1)
+	limiter := newIdentifyLimiter(gateway.SessionStartLimit, sm.client.Clock, sm.client.Logger)
+
+	// Cada shard usa a URL devolvida pelo Discord, mantendo os parâmetros configurados
+	shardConfig := *sm.Config
+	shardConfig.GatewayURL = withGatewayQuery(gateway.URL, sm.Config.GatewayURL)
+
+	shards := make([]*DiscordClient, shardCount)
+	for id := range shards {
+		opts := append(sm.opts[:len(sm.opts):len(sm.opts)], WithEventBus(sm.EventBus), WithRestClient(sm.client.Rest), withShard(id, shardCount, limiter.wait))
+		shards[id] = NewDiscordClient(&shardConfig, sm.Registry, opts...)
+	}
+
+	sm.mu.Lock()
+	sm.shards = shards
+	sm.mu.Unlock()
+
+	sm.client.logf("starting %d shard(s), max concurrency %d", shardCount, limiter.maxConcurrency)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, shardCount)
+	for _, shard := range shards {
+		wg.Add(1)
+		go func(shard *DiscordClient) {
+			defer wg.Done()
+			if err := shard.Run(ctx); err != nil {
+				errs <- fmt.Errorf("shard %d: %w", shard.ShardID, err)
+				cancel()
+			}
+		}(shard)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	// Cada shard já esperou os próprios comandos ao sair de Run; a espera é repetida aqui
+	// para que os hooks nunca rodem com um dispatch ainda em andamento
+	for _, shard := range shards {
+		shard.drain()
+	}
+
+	// Hooks de desligamento (ex.: gravar o armazenamento) rodam uma única vez, depois de todos os shards
+	sm.client.logf("all shards stopped")
+	hookErr := sm.client.runShutdownHooks()
+
+	if err := <-errs; err != nil {
+		return err
+	}
+	return hookErr
+}
+
+// Close encerra todos os shards de forma graciosa e espera Run retornar.
+func (sm *ShardManager) Close() error {
+	sm.mu.Lock()
+	cancel, done := sm.cancel, sm.done
+	sm.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+	<-done
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.runErr
+}
+
+// Status retorna o estado de todos os shards.
+func (sm *ShardManager) Status() []ShardStatus {
+	sm.mu.Lock()
+	shards := sm.shards
+	sm.mu.Unlock()
+
+	statuses := make([]ShardStatus, 0, len(shards))
+	for _, shard := range shards {
+		statuses = append(statuses, shard.Status())
+	}
+	return statuses
+}
+
+// ShardStatus retorna o estado de um shard específico.
+func (sm *ShardManager) ShardStatus(id int) (ShardStatus, bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if id < 0 || id >= len(sm.shards) {
+		return ShardStatus{}, false
+	}
+	return sm.shards[id].Status(), true
+}
+
+// Status retorna um retrato do estado da conexão deste cliente.
+func (dc *DiscordClient) Status() ShardStatus {
+	dc.mu.Lock()
+	status := ShardStatus{
+		ID:        dc.ShardID,
+		State:     dc.state,
+		SessionID: dc.sessionID,
+		Sequence:  dc.sequence,
+	}
+	dc.mu.Unlock()
+
+	if status.State == "" {
+		status.State = ShardIdle
+	}
+	status.Latency = dc.Latency()
+	status.LastHeartbeatAck = dc.LastHeartbeatAck()
+
+	return status
+}
+
+// Atualiza o estado da conexão.
+func (dc *DiscordClient) setState(state ShardState) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.state = state
+}
+
+// identifyLimiter distribui os IDENTIFY dos shards respeitando max_concurrency
+// (um IDENTIFY por bucket a cada 5 segundos) e o total de inícios de sessão disponíveis.
+type identifyLimiter struct {
+	mu             sync.Mutex
+	clock          Clock
+	logger         *log.Logger
+	maxConcurrency int
+	total          int
+	remaining      int
+	resetAt        time.Time
+	nextAllowed    map[int]time.Time // Próximo IDENTIFY permitido por bucket (shard_id % max_concurrency)
+}
+
+func newIdentifyLimiter(limit SessionStartLimit, clock Clock, logger *log.Logger) *identifyLimiter {
+	return &identifyLimiter{
+		clock:          clock,
+		logger:         logger,
+		maxConcurrency: max(limit.MaxConcurrency, 1),
+		total:          limit.Total,
+		remaining:      limit.Remaining,
+		resetAt:        clock.Now().Add(time.Duration(limit.ResetAfter) * time.Millisecond),
+		nextAllowed:    make(map[int]time.Time),
+	}
+}
+
+// Bloqueia até que o shard informado possa enviar seu IDENTIFY ou até o contexto ser cancelado.
+func (l *identifyLimiter) wait(ctx context.Context, shardID int) error {
+	l.mu.Lock()
+
+	now := l.clock.Now()
+	bucket := shardID % l.maxConcurrency
+
+	at := now
+	if next := l.nextAllowed[bucket]; next.After(at) {
+		at = next
+	}
+
+	// Sem inícios de sessão disponíveis: espera a renovação do limite
+	if l.remaining <= 0 && l.total > 0 {
+		if l.resetAt.After(at) {
+			l.logger.Printf("session start limit reached, waiting until %s", l.resetAt.Format(time.RFC3339))
+			at = l.resetAt
+		}
+		l.remaining = l.total
+		l.resetAt = at.Add(24 * time.Hour)
+	}
+
+	l.remaining--
+	l.nextAllowed[bucket] = at.Add(identifyInterval)
+
+	l.mu.Unlock()
+
+	if at.Sub(now) <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-l.clock.After(at.Sub(now)):
+		return nil
+	}
+}
@@ -0,0 +1,56 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIdentifyLimiterSpacesBuckets(t *testing.T) {
+	clock := newSupervisorClock()
+	limiter := newIdentifyLimiter(SessionStartLimit{Total: 1000, Remaining: 1000, MaxConcurrency: 2}, clock, log.New(io.Discard, "", 0))
+
+	// Shards 0, 2 e 4 dividem o bucket 0; shards 1 e 3, o bucket 1
+	for shard := range 5 {
+		if err := limiter.wait(context.Background(), shard); err != nil {
+			t.Fatalf("wait(%d): %v", shard, err)
+		}
+	}
+
+	want := []time.Duration{identifyInterval, identifyInterval, 2 * identifyInterval}
+	if got := clock.waited(); !reflect.DeepEqual(got, want) {
+		t.Errorf("esperas = %v, esperado %v", got, want)
+	}
+}
+
+func TestIdentifyLimiterWaitsForSessionStartReset(t *testing.T) {
+	clock := newSupervisorClock()
+	limiter := newIdentifyLimiter(SessionStartLimit{Total: 1000, Remaining: 1, ResetAfter: 60_000, MaxConcurrency: 4}, clock, log.New(io.Discard, "", 0))
+
+	for shard := range 2 {
+		if err := limiter.wait(context.Background(), shard); err != nil {
+			t.Fatalf("wait(%d): %v", shard, err)
+		}
+	}
+
+	// O primeiro shard usa o último início disponível; o segundo espera o limite renovar
+	if got := clock.waited(); !reflect.DeepEqual(got, []time.Duration{time.Minute}) {
+		t.Errorf("esperas = %v, esperado [1m0s]", got)
+	}
+}
+
+func TestIdentifyLimiterStopsWaitingOnCancel(t *testing.T) {
+	clock := newSupervisorClock()
+	limiter := newIdentifyLimiter(SessionStartLimit{Total: 1000, Remaining: 0, ResetAfter: 3_600_000, MaxConcurrency: 1}, clock, log.New(io.Discard, "", 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := limiter.wait(ctx, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait = %v, esperado context.Canceled", err)
+	}
+}
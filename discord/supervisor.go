@@ -0,0 +1,173 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Parâmetros do backoff exponencial entre tentativas de reconexão.
+const (
+	reconnectBaseDelay  = time.Second      // Espera da primeira tentativa
+	reconnectMaxDelay   = 2 * time.Minute  // Teto da espera entre tentativas
+	backoffResetSession = 30 * time.Second // Sessões que duram mais que isso zeram o backoff
+)
+
+// Códigos de fechamento do gateway que não adianta tentar de novo.
+var fatalCloseCodes = map[int]string{
+	4004: "authentication failed",
+	4010: "invalid shard",
+	4011: "sharding required",
+	4012: "invalid API version",
+	4013: "invalid intents",
+	4014: "disallowed intents",
+}
+
+// Códigos de fechamento que invalidam a sessão: a próxima conexão precisa fazer IDENTIFY.
+var sessionResetCloseCodes = map[int]bool{
+	4007: true, // Sequência inválida no RESUME
+	4009: true, // Sessão expirou
+}
+
+// FatalCloseError indica que o gateway fechou a conexão com um código que impede a reconexão.
+type FatalCloseError struct {
+	Code   int    // Código de fechamento recebido
+	Reason string // Descrição do código
+}
+
+func (e *FatalCloseError) Error() string {
+	return fmt.Sprintf("gateway closed with fatal code %d: %s", e.Code, e.Reason)
+}
+
+// Mantém o bot conectado ao gateway: abre a conexão, acompanha a sessão e reconecta
+// com backoff exponencial quando ela cai. Retorna diante de um código de fechamento fatal
+// ou quando o contexto é cancelado.
+func (dc *DiscordClient) supervise(ctx context.Context) error {
+	attempt := 0
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		dc.setState(ShardConnecting)
+
+		// O IDENTIFY só sai quando o bucket do shard permite; um cancelamento interrompe a espera
+		if err := dc.waitIdentify(ctx); err != nil {
+			return err
+		}
+
+		err := dc.ConnectToGateway()
+		if err == nil {
+			started := dc.Clock.Now()
+			err = dc.runSession(ctx)
+
+			// Uma sessão que ficou de pé por tempo suficiente zera o backoff
+			if dc.Clock.Now().Sub(started) > backoffResetSession {
+				attempt = 0
+			}
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if fatal := asFatalCloseError(err); fatal != nil {
+			dc.setState(ShardStopped)
+			return fatal
+		}
+
+		dc.setState(ShardReconnecting)
+
+		// Decide se a próxima conexão pode retomar a sessão ou precisa de um novo IDENTIFY
+		var reconnectErr *ReconnectError
+		var closeErr *websocket.CloseError
+		if errors.As(err, &reconnectErr) && !reconnectErr.Resume {
+			dc.ResetSession()
+		} else if errors.As(err, &closeErr) && sessionResetCloseCodes[closeErr.Code] {
+			dc.ResetSession()
+		}
+
+		// Reconexões pedidas pelo gateway (op 7 / op 9) não precisam esperar
+		if reconnectErr != nil {
+			dc.logf("reconnecting: %v", err)
+			continue
+		}
+
+		delay := backoffDelay(attempt)
+		attempt++
+		dc.logf("gateway connection lost (%v), reconnecting in %s", err, delay)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-dc.Clock.After(delay):
+		}
+	}
+}
+
+// Executa uma sessão já conectada: heartbeat e leitura de eventos rodam em goroutines próprias
+// e, quando uma delas termina, a outra é encerrada antes de retornar.
+// Se o contexto for cancelado, espera as interações em andamento e fecha a conexão com código 1000.
+func (dc *DiscordClient) runSession(ctx context.Context) error {
+	stop := make(chan struct{})
+	errs := make(chan error, 2)
+
+	go func() { errs <- dc.StartHeartbeating(stop) }()
+	go func() { errs <- dc.HandleEvents() }()
+
+	select {
+	case err := <-errs:
+		// Encerra o heartbeat e desbloqueia a leitura fechando o socket (sem código 1000,
+		// para que a sessão continue podendo ser retomada)
+		close(stop)
+		dc.stopWriter()
+		dc.WsConn.Close()
+		<-errs
+
+		return err
+	case <-ctx.Done():
+		// Para de aceitar novos dispatches e espera os comandos em andamento,
+		// mantendo o heartbeat ativo enquanto isso
+		dc.drain()
+
+		close(stop)
+		dc.stopWriter()
+		dc.closeConn(websocket.CloseNormalClosure, "shutting down")
+		<-errs
+		<-errs
+
+		dc.setState(ShardStopped)
+		return ctx.Err()
+	}
+}
+
+// Calcula a espera antes da próxima tentativa: exponencial, limitada e com jitter.
+func backoffDelay(attempt int) time.Duration {
+	delay := reconnectMaxDelay
+	if attempt < 16 {
+		delay = min(reconnectBaseDelay<<attempt, reconnectMaxDelay)
+	}
+
+	// Jitter: espera entre metade e o valor total calculado
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// Converte um erro de fechamento do WebSocket em FatalCloseError quando o código é fatal.
+func asFatalCloseError(err error) *FatalCloseError {
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		return nil
+	}
+
+	reason, fatal := fatalCloseCodes[closeErr.Code]
+	if !fatal {
+		return nil
+	}
+
+	return &FatalCloseError{Code: closeErr.Code, Reason: reason}
+}
@@ -0,0 +1,262 @@
+package discord
+
+import (
+	"bot-map/cmd"
+	"bot-map/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Conexão falsa que entrega um roteiro de payloads e termina com o erro informado
+// (io.EOF quando nenhum foi definido).
+type scriptedConn struct {
+	fakeConn
+	mu      sync.Mutex
+	reads   []GatewayPayload
+	readErr error
+	onEnd   func() // Chamada quando o roteiro acaba, antes de devolver o erro
+}
+
+func (c *scriptedConn) ReadJSON(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.reads) == 0 {
+		if c.onEnd != nil {
+			c.onEnd()
+			c.onEnd = nil
+		}
+		if c.readErr != nil {
+			return c.readErr
+		}
+		return io.EOF
+	}
+	*v.(*GatewayPayload) = c.reads[0]
+	c.reads = c.reads[1:]
+	return nil
+}
+
+// Payloads de IDENTIFY e RESUME enviados pela conexão, ignorando os heartbeats.
+func (c *scriptedConn) handshakes() []int {
+	var ops []int
+	for _, p := range c.payloads() {
+		if p.Op == OpIdentify || p.Op == OpResume {
+			ops = append(ops, p.Op)
+		}
+	}
+	return ops
+}
+
+// Relógio falso das reconexões: o tempo só anda quando o teste manda, Sleep apenas registra
+// e After registra a espera e dispara imediatamente as curtas (backoff, bucket de IDENTIFY).
+// Esperas maiores que reconnectMaxDelay, como o intervalo do heartbeat, nunca disparam.
+type supervisorClock struct {
+	fakeClock
+	waits []time.Duration
+}
+
+func (c *supervisorClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.waits = append(c.waits, d)
+	c.mu.Unlock()
+
+	if d > reconnectMaxDelay {
+		return nil
+	}
+	return c.fakeClock.After(d)
+}
+
+func (c *supervisorClock) waited() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.waits...)
+}
+
+func newSupervisorClock() *supervisorClock {
+	return &supervisorClock{fakeClock: fakeClock{now: time.Unix(1_700_000_000, 0)}}
+}
+
+// Cliente cujo Dial entrega as conexões na ordem (nil faz a conexão falhar),
+// com o IDENTIFY passando pela função gate.
+func newScriptedClient(t *testing.T, clock Clock, gate func(ctx context.Context, shardID int) error, conns ...*scriptedConn) *DiscordClient {
+	t.Helper()
+
+	var mu sync.Mutex
+	dial := func(gatewayURL string) (WebsocketConn, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(conns) == 0 {
+			t.Error("conexão além do roteiro")
+			return nil, errors.New("no more connections")
+		}
+		conn := conns[0]
+		conns = conns[1:]
+		if conn == nil {
+			return nil, errors.New("dial failed")
+		}
+		return conn, nil
+	}
+
+	return NewDiscordClient(&config.Config{Token: "token", GatewayURL: "wss://gateway.discord.gg"}, cmd.NewCommandRegistry(),
+		WithLogger(log.New(io.Discard, "", 0)),
+		WithDialer(dial),
+		WithClock(clock),
+		withShard(0, 1, gate),
+	)
+}
+
+func noGate(ctx context.Context, shardID int) error { return nil }
+
+// Hello com um intervalo de heartbeat tão longo que, na prática, o heartbeat nunca entra no teste.
+func hello(t *testing.T) GatewayPayload {
+	return payload(t, OpHello, HelloEvent{HeartbeatInterval: 1 << 40})
+}
+
+func TestSuperviseIdentifiesThroughGateAfterInvalidSession(t *testing.T) {
+	first := &scriptedConn{reads: []GatewayPayload{hello(t), payload(t, OpInvalidSession, false)}}
+	second := &scriptedConn{
+		reads:   []GatewayPayload{hello(t)},
+		readErr: &websocket.CloseError{Code: 4004, Text: "authentication failed"},
+	}
+
+	var gateCalls int
+	dc := newScriptedClient(t, newSupervisorClock(), func(ctx context.Context, shardID int) error {
+		gateCalls++
+		return nil
+	}, first, second)
+	dc.setSession("session", "wss://resume.discord.gg")
+
+	var fatal *FatalCloseError
+	if err := dc.Run(context.Background()); !errors.As(err, &fatal) {
+		t.Fatalf("Run = %v, esperado FatalCloseError", err)
+	}
+
+	// A primeira conexão retoma a sessão sem passar pelo bucket; depois do op 9 não retomável
+	// a sessão é descartada e o novo IDENTIFY espera a vez do shard
+	if ops := first.handshakes(); len(ops) != 1 || ops[0] != OpResume {
+		t.Errorf("primeira conexão enviou %v, esperado RESUME", ops)
+	}
+	if ops := second.handshakes(); len(ops) != 1 || ops[0] != OpIdentify {
+		t.Errorf("segunda conexão enviou %v, esperado IDENTIFY", ops)
+	}
+	if gateCalls != 1 {
+		t.Errorf("bucket de IDENTIFY consultado %d vezes, esperado 1", gateCalls)
+	}
+
+	var identify IdentifyData
+	if err := json.Unmarshal(second.payloads()[0].D, &identify); err != nil || identify.Token != "token" {
+		t.Errorf("IDENTIFY = %+v (%v)", identify, err)
+	}
+}
+
+func closedWith(t *testing.T, code int) *scriptedConn {
+	return &scriptedConn{reads: []GatewayPayload{hello(t)}, readErr: &websocket.CloseError{Code: code}}
+}
+
+func TestSuperviseStopsOnFatalCloseCodes(t *testing.T) {
+	for _, code := range []int{4004, 4010, 4011, 4012, 4013, 4014} {
+		dc := newScriptedClient(t, newSupervisorClock(), noGate, closedWith(t, code))
+
+		var fatal *FatalCloseError
+		if err := dc.Run(context.Background()); !errors.As(err, &fatal) || fatal.Code != code {
+			t.Errorf("código %d: Run = %v, esperado FatalCloseError", code, err)
+		}
+		if dc.Status().State != ShardStopped {
+			t.Errorf("código %d: estado = %s, esperado %s", code, dc.Status().State, ShardStopped)
+		}
+	}
+}
+
+func TestSuperviseResetsSessionOnCloseCodes(t *testing.T) {
+	for _, code := range []int{4007, 4009} {
+		first := closedWith(t, code)
+		second := closedWith(t, 4004)
+
+		dc := newScriptedClient(t, newSupervisorClock(), noGate, first, second)
+		dc.setSession("session", "wss://resume.discord.gg")
+		dc.setSequence(42)
+
+		var fatal *FatalCloseError
+		if err := dc.Run(context.Background()); !errors.As(err, &fatal) {
+			t.Fatalf("código %d: Run = %v, esperado FatalCloseError", code, err)
+		}
+
+		// A sessão invalidada não é retomada: a conexão seguinte faz IDENTIFY
+		if ops := first.handshakes(); len(ops) != 1 || ops[0] != OpResume {
+			t.Errorf("código %d: primeira conexão enviou %v, esperado RESUME", code, ops)
+		}
+		if ops := second.handshakes(); len(ops) != 1 || ops[0] != OpIdentify {
+			t.Errorf("código %d: segunda conexão enviou %v, esperado IDENTIFY", code, ops)
+		}
+	}
+}
+
+func TestSuperviseKeepsSessionOnOtherCloseCodes(t *testing.T) {
+	first := closedWith(t, CloseZombieConnection)
+	second := closedWith(t, 4004)
+
+	dc := newScriptedClient(t, newSupervisorClock(), noGate, first, second)
+	dc.setSession("session", "wss://resume.discord.gg")
+
+	var fatal *FatalCloseError
+	if err := dc.Run(context.Background()); !errors.As(err, &fatal) {
+		t.Fatalf("Run = %v, esperado FatalCloseError", err)
+	}
+	if ops := second.handshakes(); len(ops) != 1 || ops[0] != OpResume {
+		t.Errorf("segunda conexão enviou %v, esperado RESUME", ops)
+	}
+}
+
+func TestSuperviseBackoffResetsAfterLongSession(t *testing.T) {
+	clock := newSupervisorClock()
+
+	// Duas conexões falham, uma sessão fica de pé por um minuto e cai, e a última fecha com código fatal
+	long := &scriptedConn{reads: []GatewayPayload{hello(t)}, onEnd: func() { clock.advance(time.Minute) }}
+	dc := newScriptedClient(t, clock, noGate, nil, nil, long, nil, closedWith(t, 4004))
+
+	var fatal *FatalCloseError
+	if err := dc.Run(context.Background()); !errors.As(err, &fatal) {
+		t.Fatalf("Run = %v, esperado FatalCloseError", err)
+	}
+
+	// Esperas entre as tentativas, sem o jitter do primeiro heartbeat nem a espera do desligamento
+	var backoffs []time.Duration
+	for _, d := range clock.waited() {
+		if d >= reconnectBaseDelay/2 && d < defaultShutdownTimeout {
+			backoffs = append(backoffs, d)
+		}
+	}
+
+	// Tentativas 0 e 1, a sessão longa zera o contador, depois tentativas 0 e 1 de novo
+	ranges := [][2]time.Duration{
+		{reconnectBaseDelay / 2, reconnectBaseDelay},
+		{reconnectBaseDelay, 2 * reconnectBaseDelay},
+		{reconnectBaseDelay / 2, reconnectBaseDelay},
+		{reconnectBaseDelay, 2 * reconnectBaseDelay},
+	}
+	if len(backoffs) != len(ranges) {
+		t.Fatalf("esperas = %v, esperado %d esperas de backoff", backoffs, len(ranges))
+	}
+	for idx, d := range backoffs {
+		if d < ranges[idx][0] || d > ranges[idx][1] {
+			t.Errorf("espera %d = %s, esperado entre %s e %s", idx+1, d, ranges[idx][0], ranges[idx][1])
+		}
+	}
+}
+
+func TestBackoffDelayIsCapped(t *testing.T) {
+	for attempt := range 40 {
+		delay := backoffDelay(attempt)
+		if delay < reconnectBaseDelay/2 || delay > reconnectMaxDelay {
+			t.Errorf("backoffDelay(%d) = %s, fora de [%s, %s]", attempt, delay, reconnectBaseDelay/2, reconnectMaxDelay)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package discord
+
+import (
+	"bot-map/cmd"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ApplicationCommand é a definição de um comando slash como o Discord a armazena.
+type ApplicationCommand struct {
+	ID          string                                `json:"id,omitempty"`      // ID do comando (preenchido pelo Discord)
+	Type        int                                   `json:"type,omitempty"`    // Tipo do comando (1 = slash command)
+	Name        string                                `json:"name"`              // Nome do comando
+	Description string                                `json:"description"`       // Descrição exibida no Discord
+	Options     []*discordgo.ApplicationCommandOption `json:"options,omitempty"` // Opções declaradas
+
+	DefaultMemberPermissions *string                  `json:"default_member_permissions,omitempty"` // Permissões padrão (bitfield em texto)
+	Contexts                 []cmd.InteractionContext `json:"contexts,omitempty"`                   // Onde o comando global pode ser usado
+	IntegrationTypes         []cmd.IntegrationType    `json:"integration_types,omitempty"`          // Instalações em que o comando global aparece
+}
+
+// CommandDiff descreve o que a sincronização muda nos comandos registrados no Discord.
+type CommandDiff struct {
+	Create []string // Comandos que ainda não existem no Discord
+	Update []string // Comandos cuja definição mudou
+	Delete []string // Comandos que não estão mais no registro
+}
+
+// Empty indica se os comandos do Discord já estão iguais aos do registro.
+func (d *CommandDiff) Empty() bool {
+	return len(d.Create) == 0 && len(d.Update) == 0 && len(d.Delete) == 0
+}
+
+// Função para registrar comandos slash no Discord.
+// Sincroniza os comandos de cada escopo com o registro; com Config.CommandSyncDryRun apenas mostra as diferenças.
+func (dc *DiscordClient) RegisterSlashCommands() error {
+	_, err := dc.SyncCommands(context.Background(), dc.Config.CommandSyncDryRun)
+	return err
+}
+
+// Destino da sincronização: os comandos globais ou os de uma guilda.
+type commandTarget struct {
+	label string // Nome usado nos logs ("global" ou "guild <id>")
+	path  string // Caminho da lista de comandos na API
+}
+
+// SyncCommands compara os comandos de cada escopo (global, guilda de desenvolvimento e guildas declaradas)
+// com os do registro e, onde houver diferença, substitui todos de uma vez com PUT.
+// Executar de novo sem mudanças no registro não altera nada. Em dryRun, só registra o diff no log.
+// O resultado traz o diff de cada destino, indexado pelo mesmo nome usado nos logs.
+//
+// Guildas que deixaram de aparecer em todos os escopos não são visitadas, então os comandos
+// antigos delas precisam ser removidos manualmente.
+func (dc *DiscordClient) SyncCommands(ctx context.Context, dryRun bool) (map[string]*CommandDiff, error) {
+	targets, desired := dc.commandTargets()
+	diffs := make(map[string]*CommandDiff, len(targets))
+
+	for _, target := range targets {
+		diff, err := dc.syncTarget(ctx, target, desired[target.label], dryRun)
+		if err != nil {
+			return diffs, err
+		}
+		diffs[target.label] = diff
+	}
+
+	return diffs, nil
+}
+
+// Distribui os comandos do registro entre os destinos de acordo com o escopo de cada um.
+// O destino global e a guilda de desenvolvimento são sempre sincronizados, para que comandos
+// que mudaram de escopo sejam removidos do lugar antigo.
+func (dc *DiscordClient) commandTargets() ([]commandTarget, map[string][]*ApplicationCommand) {
+	appID := dc.Config.ApplicationID
+
+	global := commandTarget{label: "global", path: fmt.Sprintf("/applications/%s/commands", appID)}
+	targets := []commandTarget{global}
+	desired := map[string][]*ApplicationCommand{global.label: {}}
+
+	guildTarget := func(guildID string) commandTarget {
+		target := commandTarget{label: "guild " + guildID, path: fmt.Sprintf("/applications/%s/guilds/%s/commands", appID, guildID)}
+		if _, ok := desired[target.label]; !ok {
+			targets = append(targets, target)
+			desired[target.label] = []*ApplicationCommand{}
+		}
+		return target
+	}
+
+	if dc.Config.GuildID != "" {
+		guildTarget(dc.Config.GuildID)
+	}
+
+	infos := dc.Registry.GetAllCommands()
+	sort.Slice(infos, func(a, b int) bool { return infos[a].Name < infos[b].Name })
+
+	for _, info := range infos {
+		switch info.Scope.Kind {
+		case cmd.ScopeGlobal:
+			desired[global.label] = append(desired[global.label], applicationCommand(info, true))
+		case cmd.ScopeGuilds:
+			for _, guildID := range info.Scope.GuildIDs {
+				target := guildTarget(guildID)
+				desired[target.label] = append(desired[target.label], applicationCommand(info, false))
+			}
+		default:
+			if dc.Config.GuildID == "" {
+				dc.logf("command sync: /%s is dev-guild-only but GUILD_ID is not set, skipping", info.Name)
+				continue
+			}
+			target := guildTarget(dc.Config.GuildID)
+			desired[target.label] = append(desired[target.label], applicationCommand(info, false))
+		}
+	}
+
+	return targets, desired
+}
+
+// Sincroniza os comandos de um destino.
+func (dc *DiscordClient) syncTarget(ctx context.Context, target commandTarget, desired []*ApplicationCommand, dryRun bool) (*CommandDiff, error) {
+	var existing []*ApplicationCommand
+	if err := dc.Rest.Do(ctx, "GET", target.path, nil, &existing); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s commands: %w", target.label, err)
+	}
+
+	diff := diffCommands(existing, desired)
+
+	for _, name := range diff.Create {
+		dc.logf("command sync (%s): create /%s", target.label, name)
+	}
+	for _, name := range diff.Update {
+		dc.logf("command sync (%s): update /%s", target.label, name)
+	}
+	for _, name := range diff.Delete {
+		dc.logf("command sync (%s): delete /%s", target.label, name)
+	}
+
+	if diff.Empty() {
+		dc.logf("command sync (%s): %d commands up to date", target.label, len(desired))
+		return diff, nil
+	}
+
+	if dryRun {
+		dc.logf("command sync (%s): dry run, no changes applied", target.label)
+		return diff, nil
+	}
+
+	if err := dc.Rest.Do(ctx, "PUT", target.path, desired, nil); err != nil {
+		return diff, fmt.Errorf("failed to overwrite %s commands: %w", target.label, err)
+	}
+
+	return diff, nil
+}
+
+// Converte um comando do registro na definição enviada ao Discord.
+// contexts e integration_types só valem para comandos globais, então ficam de fora nas guildas.
+func applicationCommand(info *cmd.CommandInfo, global bool) *ApplicationCommand {
+	declared := info.ApplicationOptions()
+	options := make([]*discordgo.ApplicationCommandOption, len(declared))
+	for idx := range declared {
+		options[idx] = &declared[idx]
+	}
+
+	command := &ApplicationCommand{
+		Type:        int(discordgo.ChatApplicationCommand),
+		Name:        info.Name,
+		Description: info.Description,
+		Options:     options,
+	}
+
+	if info.DefaultMemberPermissions != nil {
+		permissions := strconv.FormatInt(*info.DefaultMemberPermissions, 10)
+		command.DefaultMemberPermissions = &permissions
+	}
+
+	if global {
+		command.Contexts = info.Contexts
+		command.IntegrationTypes = info.IntegrationTypes
+	}
+
+	return command
+}
+
+// Compara os comandos existentes no Discord com os desejados, pelo nome.
+func diffCommands(existing, desired []*ApplicationCommand) *CommandDiff {
+	diff := &CommandDiff{}
+
+	current := make(map[string]*ApplicationCommand, len(existing))
+	for _, command := range existing {
+		current[command.Name] = command
+	}
+
+	for _, command := range desired {
+		old, ok := current[command.Name]
+		switch {
+		case !ok:
+			diff.Create = append(diff.Create, command.Name)
+		case !sameCommand(old, command):
+			diff.Update = append(diff.Update, command.Name)
+		}
+		delete(current, command.Name)
+	}
+
+	for name := range current {
+		diff.Delete = append(diff.Delete, name)
+	}
+	sort.Strings(diff.Delete)
+
+	return diff
+}
+
+// Compara a definição existente com a desejada ignorando os campos preenchidos pelo Discord (ID)
+// e as diferenças entre listas vazias e ausentes. Quando o registro não declara contexts ou
+// integration_types, os valores padrão que o Discord devolve não contam como diferença.
+func sameCommand(existing, desired *ApplicationCommand) bool {
+	current := *existing
+	if desired.Contexts == nil {
+		current.Contexts = nil
+	}
+	if desired.IntegrationTypes == nil {
+		current.IntegrationTypes = nil
+	}
+	return canonicalCommand(&current) == canonicalCommand(desired)
+}
+
+// Serializa a definição em uma forma canônica para comparação.
+func canonicalCommand(command *ApplicationCommand) string {
+	normalized := *command
+	normalized.ID = ""
+	if normalized.Type == 0 {
+		normalized.Type = int(discordgo.ChatApplicationCommand)
+	}
+	normalized.Options = normalizeOptions(command.Options)
+
+	data, _ := json.Marshal(normalized)
+	return string(data)
+}
+
+// Troca listas vazias por nil, já que o Discord omite esses campos nas respostas.
+func normalizeOptions(options []*discordgo.ApplicationCommandOption) []*discordgo.ApplicationCommandOption {
+	if len(options) == 0 {
+		return nil
+	}
+
+	normalized := make([]*discordgo.ApplicationCommandOption, len(options))
+	for idx, option := range options {
+		copied := *option
+		copied.Options = normalizeOptions(option.Options)
+		if len(copied.Choices) == 0 {
+			copied.Choices = nil
+		}
+		if len(copied.ChannelTypes) == 0 {
+			copied.ChannelTypes = nil
+		}
+		normalized[idx] = &copied
+	}
+
+	return normalized
+}
@@ -0,0 +1,315 @@
+package discord
+
+import (
+	"bot-map/cmd"
+	"bot-map/config"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Comando com subcomandos, opção obrigatória, valor mínimo e escolhas, como os do bot.
+func syncTestCommand(scope cmd.CommandScope) *cmd.CommandInfo {
+	minVersao := 1.0
+	permissions := int64(32) // Gerenciar servidor
+
+	return &cmd.CommandInfo{
+		Name:        "local",
+		Description: "Localidades",
+		Scope:       scope,
+		Subcommands: []*cmd.SubcommandInfo{
+			{
+				Name:        "adicionar",
+				Description: "Adiciona",
+				Options: []discordgo.ApplicationCommandOption{
+					{Type: discordgo.ApplicationCommandOptionString, Name: "nome", Description: "Nome", Required: true},
+					{Type: discordgo.ApplicationCommandOptionInteger, Name: "versao", Description: "Versão", MinValue: &minVersao},
+					{Type: discordgo.ApplicationCommandOptionString, Name: "categoria", Description: "Categoria", Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Cidade", Value: "cidade"},
+					}},
+				},
+			},
+			{Name: "listar", Description: "Lista"},
+		},
+		DefaultMemberPermissions: &permissions,
+	}
+}
+
+// O mesmo comando como o Discord devolve em GET: campos preenchidos pelo Discord, campos vazios
+// omitidos e contexts/integration_types com os valores padrão.
+const discordLocalCommand = `{
+	"id": "1100000000000000000",
+	"application_id": "1000000000000000000",
+	"version": "1100000000000000001",
+	"type": 1,
+	"name": "local",
+	"description": "Localidades",
+	"default_member_permissions": "32",
+	"dm_permission": true,
+	"nsfw": false,
+	"contexts": [0, 1, 2],
+	"integration_types": [0],
+	"options": [
+		{"type": 1, "name": "adicionar", "description": "Adiciona", "options": [
+			{"type": 3, "name": "nome", "description": "Nome", "required": true},
+			{"type": 4, "name": "versao", "description": "Versão", "min_value": 1},
+			{"type": 3, "name": "categoria", "description": "Categoria", "choices": [{"name": "Cidade", "value": "cidade"}]}
+		]},
+		{"type": 1, "name": "listar", "description": "Lista"}
+	]
+}`
+
+func decodeCommands(t *testing.T, data string) []*ApplicationCommand {
+	t.Helper()
+
+	var commands []*ApplicationCommand
+	if err := json.Unmarshal([]byte(data), &commands); err != nil {
+		t.Fatal(err)
+	}
+	return commands
+}
+
+func TestDiffCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		desired  []*ApplicationCommand
+		want     CommandDiff
+	}{
+		{
+			name:     "resposta do Discord igual ao registro",
+			existing: "[" + discordLocalCommand + "]",
+			desired:  []*ApplicationCommand{applicationCommand(syncTestCommand(cmd.DevGuild()), false)},
+		},
+		{
+			name:     "comando global sem contexts declarados",
+			existing: "[" + discordLocalCommand + "]",
+			desired:  []*ApplicationCommand{applicationCommand(syncTestCommand(cmd.Global()), true)},
+		},
+		{
+			name:     "contexts declarados diferentes dos do Discord",
+			existing: "[" + discordLocalCommand + "]",
+			desired: func() []*ApplicationCommand {
+				info := syncTestCommand(cmd.Global())
+				info.Contexts = []cmd.InteractionContext{cmd.ContextGuild}
+				return []*ApplicationCommand{applicationCommand(info, true)}
+			}(),
+			want: CommandDiff{Update: []string{"local"}},
+		},
+		{
+			name:     "integration_types declarados iguais aos do Discord",
+			existing: "[" + discordLocalCommand + "]",
+			desired: func() []*ApplicationCommand {
+				info := syncTestCommand(cmd.Global())
+				info.IntegrationTypes = []cmd.IntegrationType{cmd.IntegrationGuildInstall}
+				return []*ApplicationCommand{applicationCommand(info, true)}
+			}(),
+		},
+		{
+			name:     "descrição alterada",
+			existing: "[" + discordLocalCommand + "]",
+			desired: func() []*ApplicationCommand {
+				info := syncTestCommand(cmd.DevGuild())
+				info.Description = "Localidades do mapa"
+				return []*ApplicationCommand{applicationCommand(info, false)}
+			}(),
+			want: CommandDiff{Update: []string{"local"}},
+		},
+		{
+			name:     "criação e remoção",
+			existing: `[{"id": "1", "name": "ping", "description": "Ping"}, {"id": "2", "name": "antigo", "description": "Antigo"}]`,
+			desired: []*ApplicationCommand{
+				{Name: "ping", Description: "Ping", Options: []*discordgo.ApplicationCommandOption{}},
+				applicationCommand(syncTestCommand(cmd.DevGuild()), false),
+			},
+			want: CommandDiff{Create: []string{"local"}, Delete: []string{"antigo"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := diffCommands(decodeCommands(t, test.existing), test.desired)
+			if !reflect.DeepEqual(*got, test.want) {
+				t.Errorf("diff = %+v, esperado %+v", *got, test.want)
+			}
+		})
+	}
+}
+
+// API de comandos falsa: responde os GET com a lista de cada caminho e registra os PUT.
+type fakeCommandsAPI struct {
+	mu       sync.Mutex
+	commands map[string]string // Caminho -> JSON devolvido no GET
+	puts     map[string][]*ApplicationCommand
+}
+
+func (api *fakeCommandsAPI) do(req *http.Request) *http.Response {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	path := strings.TrimPrefix(req.URL.Path, "/api")
+	switch req.Method {
+	case "GET":
+		body, ok := api.commands[path]
+		if !ok {
+			body = "[]"
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}
+	case "PUT":
+		var commands []*ApplicationCommand
+		json.NewDecoder(req.Body).Decode(&commands)
+		api.puts[path] = commands
+	}
+	return emptyResponse(http.Header{})
+}
+
+func TestSyncCommandsUpToDateSendsNoPut(t *testing.T) {
+	api := &fakeCommandsAPI{
+		commands: map[string]string{"/applications/1/guilds/20/commands": "[" + discordLocalCommand + "]"},
+		puts:     make(map[string][]*ApplicationCommand),
+	}
+
+	registry := cmd.NewCommandRegistry()
+	registry.RegistryCommand(syncTestCommand(cmd.DevGuild()))
+
+	dc := NewDiscordClient(&config.Config{BaseURL: "https://discord.test/api", ApplicationID: "1", GuildID: "20"}, registry,
+		WithLogger(log.New(io.Discard, "", 0)),
+		WithHTTPClient(fakeHTTPClient(api.do)),
+	)
+
+	if _, err := dc.SyncCommands(context.Background(), false); err != nil {
+		t.Fatalf("SyncCommands: %v", err)
+	}
+	if len(api.puts) != 0 {
+		t.Errorf("sincronização sem mudanças enviou PUT: %v", api.puts)
+	}
+}
+
+func TestSyncCommandsDryRunSendsNoPut(t *testing.T) {
+	api := &fakeCommandsAPI{
+		commands: map[string]string{"/applications/1/guilds/20/commands": `[{"id": "2", "name": "antigo", "description": "Antigo"}]`},
+		puts:     make(map[string][]*ApplicationCommand),
+	}
+
+	registry := cmd.NewCommandRegistry()
+	registry.RegistryCommand(syncTestCommand(cmd.DevGuild()))
+
+	dc := NewDiscordClient(&config.Config{BaseURL: "https://discord.test/api", ApplicationID: "1", GuildID: "20"}, registry,
+		WithLogger(log.New(io.Discard, "", 0)),
+		WithHTTPClient(fakeHTTPClient(api.do)),
+	)
+
+	diffs, err := dc.SyncCommands(context.Background(), true)
+	if err != nil {
+		t.Fatalf("SyncCommands: %v", err)
+	}
+	want := CommandDiff{Create: []string{"local"}, Delete: []string{"antigo"}}
+	if got := diffs["guild 20"]; !reflect.DeepEqual(*got, want) {
+		t.Errorf("diff = %+v, esperado %+v", *got, want)
+	}
+	if len(api.puts) != 0 {
+		t.Errorf("dry run enviou PUT: %v", api.puts)
+	}
+}
+
+func TestCommandTargets(t *testing.T) {
+	registry := cmd.NewCommandRegistry()
+	registry.RegistryCommand(&cmd.CommandInfo{Name: "dev", Description: "Dev"})
+	registry.RegistryCommand(&cmd.CommandInfo{Name: "global", Description: "Global", Scope: cmd.Global(),
+		Contexts: []cmd.InteractionContext{cmd.ContextGuild}})
+	registry.RegistryCommand(&cmd.CommandInfo{Name: "parceiros", Description: "Parceiros", Scope: cmd.Guilds("30", "40"),
+		Contexts: []cmd.InteractionContext{cmd.ContextGuild}})
+
+	dc := NewDiscordClient(&config.Config{ApplicationID: "1", GuildID: "20"}, registry,
+		WithLogger(log.New(io.Discard, "", 0)))
+	targets, desired := dc.commandTargets()
+
+	var paths []string
+	for _, target := range targets {
+		paths = append(paths, target.label+" "+target.path)
+	}
+	wantPaths := []string{
+		"global /applications/1/commands",
+		"guild 20 /applications/1/guilds/20/commands",
+		"guild 30 /applications/1/guilds/30/commands",
+		"guild 40 /applications/1/guilds/40/commands",
+	}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("destinos = %q, esperado %q", paths, wantPaths)
+	}
+
+	names := func(label string) []string {
+		var result []string
+		for _, command := range desired[label] {
+			result = append(result, command.Name)
+		}
+		return result
+	}
+	for label, want := range map[string][]string{
+		"global":   {"global"},
+		"guild 20": {"dev"},
+		"guild 30": {"parceiros"},
+		"guild 40": {"parceiros"},
+	} {
+		if got := names(label); !reflect.DeepEqual(got, want) {
+			t.Errorf("comandos de %s = %v, esperado %v", label, got, want)
+		}
+	}
+
+	// contexts só vai para o destino global
+	if desired["global"][0].Contexts == nil || desired["guild 30"][0].Contexts != nil {
+		t.Errorf("contexts: global %v, guilda %v", desired["global"][0].Contexts, desired["guild 30"][0].Contexts)
+	}
+}
+
+func TestSyncCommandsMovesCommandFromDevGuildToGlobal(t *testing.T) {
+	for _, dryRun := range []bool{true, false} {
+		// /local estava na guilda de desenvolvimento e agora é global
+		api := &fakeCommandsAPI{
+			commands: map[string]string{"/applications/1/guilds/20/commands": "[" + discordLocalCommand + "]"},
+			puts:     make(map[string][]*ApplicationCommand),
+		}
+
+		registry := cmd.NewCommandRegistry()
+		registry.RegistryCommand(syncTestCommand(cmd.Global()))
+
+		dc := NewDiscordClient(&config.Config{BaseURL: "https://discord.test/api", ApplicationID: "1", GuildID: "20"}, registry,
+			WithLogger(log.New(io.Discard, "", 0)),
+			WithHTTPClient(fakeHTTPClient(api.do)),
+		)
+
+		diffs, err := dc.SyncCommands(context.Background(), dryRun)
+		if err != nil {
+			t.Fatalf("SyncCommands(dryRun=%t): %v", dryRun, err)
+		}
+
+		if got := diffs["global"]; !reflect.DeepEqual(got.Create, []string{"local"}) || len(got.Update)+len(got.Delete) != 0 {
+			t.Errorf("dryRun=%t: diff global = %+v", dryRun, got)
+		}
+		if got := diffs["guild 20"]; !reflect.DeepEqual(got.Delete, []string{"local"}) || len(got.Create)+len(got.Update) != 0 {
+			t.Errorf("dryRun=%t: diff da guilda = %+v", dryRun, got)
+		}
+
+		if dryRun {
+			if len(api.puts) != 0 {
+				t.Errorf("dry run enviou PUT para %d destinos", len(api.puts))
+			}
+			continue
+		}
+
+		if global := api.puts["/applications/1/commands"]; len(global) != 1 || global[0].Name != "local" {
+			t.Errorf("PUT global = %+v", global)
+		}
+		if guild, ok := api.puts["/applications/1/guilds/20/commands"]; !ok || len(guild) != 0 {
+			t.Errorf("PUT da guilda = %+v (enviado: %t), esperado lista vazia", guild, ok)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package interaction
+
+import "context"
+
+// Followups permite que um comando continue conversando com o usuário depois da primeira resposta:
+// editar ou apagar a resposta original e enviar mensagens adicionais.
+type Followups interface {
+	EditOriginal(ctx context.Context, data *ResponseData) error
+	DeleteOriginal(ctx context.Context) error
+	Send(ctx context.Context, data *ResponseData) error
+}
+
+type followupsKey struct{}
+
+// WithFollowups guarda no contexto o acesso às mensagens de acompanhamento da interação.
+func WithFollowups(ctx context.Context, followups Followups) context.Context {
+	return context.WithValue(ctx, followupsKey{}, followups)
+}
+
+// FollowupsFrom recupera do contexto o acesso às mensagens de acompanhamento da interação.
+func FollowupsFrom(ctx context.Context) (Followups, bool) {
+	followups, ok := ctx.Value(followupsKey{}).(Followups)
+	return followups, ok
+}
@@ -0,0 +1,254 @@
+package interaction
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Type é o tipo de uma interação.
+type Type int
+
+const (
+	TypePing               Type = 1 // Ping (apenas em interações via HTTP)
+	TypeApplicationCommand Type = 2 // Execução de um slash command
+	TypeMessageComponent   Type = 3 // Clique em botão ou seleção em menu
+	TypeAutocomplete       Type = 4 // Autocomplete de uma opção de comando
+	TypeModalSubmit        Type = 5 // Envio de um modal
+)
+
+// OptionType é o tipo de uma opção de comando.
+type OptionType int
+
+const (
+	OptionSubCommand      OptionType = 1
+	OptionSubCommandGroup OptionType = 2
+	OptionString          OptionType = 3
+	OptionInteger         OptionType = 4
+	OptionBoolean         OptionType = 5
+	OptionUser            OptionType = 6
+	OptionChannel         OptionType = 7
+	OptionRole            OptionType = 8
+	OptionMentionable     OptionType = 9
+	OptionNumber          OptionType = 10
+	OptionAttachment      OptionType = 11
+)
+
+// Locale é o idioma informado pelo cliente do Discord (ex.: "pt-BR", "en-US").
+type Locale string
+
+const (
+	LocalePortugueseBR Locale = "pt-BR"
+	LocaleEnglishUS    Locale = "en-US"
+	LocaleEnglishGB    Locale = "en-GB"
+	LocaleSpanishES    Locale = "es-ES"
+)
+
+// Erros devolvidos quando o payload de uma interação é inválido.
+var (
+	ErrMissingID      = errors.New("interaction without id")
+	ErrMissingToken   = errors.New("interaction without token")
+	ErrMissingData    = errors.New("interaction without command data")
+	ErrUnknownType    = errors.New("unknown interaction type")
+	ErrMissingCommand = errors.New("interaction without command name")
+)
+
+// Interaction representa uma interação recebida do Discord (INTERACTION_CREATE).
+type Interaction struct {
+	ID             string                  `json:"id"`
+	ApplicationID  string                  `json:"application_id"`
+	Type           Type                    `json:"type"`
+	Data           *ApplicationCommandData `json:"data"`
+	GuildID        string                  `json:"guild_id"`
+	ChannelID      string                  `json:"channel_id"`
+	Member         *Member                 `json:"member"` // Presente em interações dentro de guildas
+	User           *User                   `json:"user"`   // Presente em interações por DM
+	Token          string                  `json:"token"`
+	Version        int                     `json:"version"`
+	AppPermissions string                  `json:"app_permissions"`
+	Locale         Locale                  `json:"locale"`
+	GuildLocale    Locale                  `json:"guild_locale"`
+}
+
+// ApplicationCommandData são os dados do comando invocado.
+type ApplicationCommandData struct {
+	ID       string         `json:"id"`
+	Name     string         `json:"name"`
+	Type     int            `json:"type"`
+	Resolved *ResolvedData  `json:"resolved"`
+	Options  []*OptionValue `json:"options"`
+	GuildID  string         `json:"guild_id"`
+	TargetID string         `json:"target_id"`
+	Values   []string       `json:"values"`    // Valores selecionados em menus (componentes)
+	CustomID string         `json:"custom_id"` // ID do componente ou modal
+}
+
+// OptionValue é o valor de uma opção preenchida pelo usuário.
+type OptionValue struct {
+	Name    string          `json:"name"`
+	Type    OptionType      `json:"type"`
+	Value   json.RawMessage `json:"value"`
+	Options []*OptionValue  `json:"options"` // Opções de subcomandos e grupos
+	Focused bool            `json:"focused"` // Opção sendo preenchida no autocomplete
+}
+
+// User representa um usuário do Discord.
+type User struct {
+	ID            string `json:"id"`
+	Username      string `json:"username"`
+	GlobalName    string `json:"global_name"`
+	Discriminator string `json:"discriminator"`
+	Avatar        string `json:"avatar"`
+	Bot           bool   `json:"bot"`
+}
+
+// Member representa o membro de uma guilda que invocou a interação.
+type Member struct {
+	User        *User     `json:"user"`
+	Nick        string    `json:"nick"`
+	Roles       []string  `json:"roles"`
+	JoinedAt    time.Time `json:"joined_at"`
+	Permissions string    `json:"permissions"` // Bitfield de permissões no canal, serializado como string
+}
+
+// PermissionBits converte o bitfield de permissões do membro; valores inválidos viram zero.
+func (m *Member) PermissionBits() int64 {
+	bits, err := strconv.ParseUint(m.Permissions, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return int64(bits)
+}
+
+// HasRole indica se o membro tem o cargo informado.
+func (m *Member) HasRole(roleID string) bool {
+	for _, role := range m.Roles {
+		if role == roleID {
+			return true
+		}
+	}
+	return false
+}
+
+// Role representa um cargo resolvido.
+type Role struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Color       int    `json:"color"`
+	Permissions string `json:"permissions"`
+}
+
+// Channel representa um canal resolvido.
+type Channel struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Type     int    `json:"type"`
+	ParentID string `json:"parent_id"`
+}
+
+// Attachment representa um arquivo enviado como opção.
+type Attachment struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+	URL         string `json:"url"`
+}
+
+// ResolvedData contém os objetos completos referenciados pelas opções (por ID).
+type ResolvedData struct {
+	Users       map[string]*User       `json:"users"`
+	Members     map[string]*Member     `json:"members"`
+	Roles       map[string]*Role       `json:"roles"`
+	Channels    map[string]*Channel    `json:"channels"`
+	Attachments map[string]*Attachment `json:"attachments"`
+}
+
+// Parse decodifica e valida o payload de uma interação.
+// Payloads malformados ou de tipos desconhecidos retornam erro em vez de causar panic.
+func Parse(data []byte) (*Interaction, error) {
+	var interaction Interaction
+	if err := json.Unmarshal(data, &interaction); err != nil {
+		return nil, fmt.Errorf("invalid interaction payload: %w", err)
+	}
+
+	if err := interaction.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &interaction, nil
+}
+
+// Validate verifica se a interação tem os campos necessários para ser respondida.
+func (i *Interaction) Validate() error {
+	if i.ID == "" {
+		return ErrMissingID
+	}
+	if i.Token == "" {
+		return ErrMissingToken
+	}
+
+	switch i.Type {
+	case TypePing:
+	case TypeApplicationCommand, TypeAutocomplete:
+		if i.Data == nil {
+			return ErrMissingData
+		}
+		if i.Data.Name == "" {
+			return ErrMissingCommand
+		}
+	case TypeMessageComponent, TypeModalSubmit:
+		if i.Data == nil {
+			return ErrMissingData
+		}
+	default:
+		return fmt.Errorf("%w: %d", ErrUnknownType, i.Type)
+	}
+
+	return nil
+}
+
+// Invoker retorna o usuário que invocou a interação, esteja ela em uma guilda ou em DM.
+func (i *Interaction) Invoker() *User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// CommandName retorna o nome do comando invocado.
+func (i *Interaction) CommandName() string {
+	if i.Data == nil {
+		return ""
+	}
+	return i.Data.Name
+}
+
+// Época dos snowflakes do Discord (primeiro segundo de 2015), em milissegundos.
+const discordEpoch = 1420070400000
+
+// Tempo de validade do token de uma interação.
+const TokenLifetime = 15 * time.Minute
+
+// SnowflakeTime retorna o momento de criação codificado em um ID (snowflake) do Discord.
+func SnowflakeTime(id string) (time.Time, error) {
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid snowflake %q: %w", id, err)
+	}
+	return time.UnixMilli(int64(n>>22) + discordEpoch), nil
+}
+
+// CreatedAt retorna o momento em que a interação foi criada.
+func (i *Interaction) CreatedAt() time.Time {
+	created, _ := SnowflakeTime(i.ID)
+	return created
+}
+
+// Expired indica se o token da interação já expirou (15 minutos após a criação).
+func (i *Interaction) Expired(now time.Time) bool {
+	created := i.CreatedAt()
+	return !created.IsZero() && now.Sub(created) > TokenLifetime
+}
@@ -0,0 +1,170 @@
+package interaction
+
+// ResponseType é o tipo de resposta (callback) a uma interação.
+type ResponseType int
+
+const (
+	ResponsePong                   ResponseType = 1 // Resposta a um ping
+	ResponseChannelMessage         ResponseType = 4 // Mensagem imediata no canal
+	ResponseDeferredChannelMessage ResponseType = 5 // "Pensando...", mensagem enviada depois
+	ResponseDeferredUpdate         ResponseType = 6 // Atualização adiada da mensagem do componente
+	ResponseUpdateMessage          ResponseType = 7 // Atualiza a mensagem do componente
+	ResponseAutocomplete           ResponseType = 8 // Sugestões de autocomplete
+	ResponseModal                  ResponseType = 9 // Abre um modal
+)
+
+// Flags de mensagem.
+const (
+	FlagEphemeral = 1 << 6 // Mensagem visível apenas para quem invocou o comando
+)
+
+// Response é a resposta de um comando, entregue ao Discord pelo responder do pacote discord.
+type Response struct {
+	Type ResponseType  `json:"type"`
+	Data *ResponseData `json:"data,omitempty"`
+}
+
+// ResponseData é o conteúdo da resposta.
+type ResponseData struct {
+	Content    string      `json:"content,omitempty"`
+	Embeds     []Embed     `json:"embeds,omitempty"`
+	Flags      int         `json:"flags,omitempty"`
+	Choices    []Choice    `json:"choices,omitempty"`    // Sugestões de autocomplete
+	CustomID   string      `json:"custom_id,omitempty"`  // ID do modal
+	Title      string      `json:"title,omitempty"`      // Título do modal
+	Components []Component `json:"components,omitempty"` // Linhas de componentes (ex.: campos do modal)
+}
+
+// Embed é uma mensagem rica (título, descrição, campos, imagem...).
+type Embed struct {
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	URL         string       `json:"url,omitempty"`
+	Color       int          `json:"color,omitempty"`
+	Timestamp   string       `json:"timestamp,omitempty"`
+	Fields      []EmbedField `json:"fields,omitempty"`
+	Image       *EmbedImage  `json:"image,omitempty"`
+	Thumbnail   *EmbedImage  `json:"thumbnail,omitempty"`
+	Footer      *EmbedFooter `json:"footer,omitempty"`
+}
+
+// EmbedField é um campo (nome e valor) de um embed.
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// EmbedImage é uma imagem de um embed.
+type EmbedImage struct {
+	URL string `json:"url"`
+}
+
+// EmbedFooter é o rodapé de um embed.
+type EmbedFooter struct {
+	Text string `json:"text"`
+}
+
+// Choice é uma sugestão de autocomplete.
+type Choice struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// Tipos e estilos de componentes.
+const (
+	ComponentActionRow = 1 // Linha que agrupa outros componentes
+	ComponentTextInput = 4 // Campo de texto (somente em modais)
+
+	TextInputShort     = 1 // Campo de uma linha
+	TextInputParagraph = 2 // Campo de várias linhas
+)
+
+// Component é um componente de mensagem ou modal.
+type Component struct {
+	Type        int         `json:"type"`
+	CustomID    string      `json:"custom_id,omitempty"`
+	Style       int         `json:"style,omitempty"`
+	Label       string      `json:"label,omitempty"`
+	Placeholder string      `json:"placeholder,omitempty"`
+	Value       string      `json:"value,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	MinLength   int         `json:"min_length,omitempty"`
+	MaxLength   int         `json:"max_length,omitempty"`
+	Components  []Component `json:"components,omitempty"`
+}
+
+// Message cria uma resposta de mensagem simples.
+func Message(content string) *Response {
+	return &Response{
+		Type: ResponseChannelMessage,
+		Data: &ResponseData{Content: content},
+	}
+}
+
+// EphemeralMessage cria uma mensagem visível apenas para quem invocou o comando.
+func EphemeralMessage(content string) *Response {
+	return &Response{
+		Type: ResponseChannelMessage,
+		Data: &ResponseData{Content: content, Flags: FlagEphemeral},
+	}
+}
+
+// EmbedMessage cria uma resposta com um ou mais embeds.
+func EmbedMessage(embeds ...Embed) *Response {
+	return &Response{
+		Type: ResponseChannelMessage,
+		Data: &ResponseData{Embeds: embeds},
+	}
+}
+
+// Quantidade máxima de sugestões aceitas pelo Discord em um autocomplete.
+const MaxChoices = 25
+
+// Autocomplete cria a resposta com as sugestões de autocomplete.
+// O Discord exige a lista, mesmo vazia, e aceita no máximo MaxChoices sugestões.
+func Autocomplete(choices ...Choice) *Response {
+	if len(choices) > MaxChoices {
+		choices = choices[:MaxChoices]
+	}
+	if choices == nil {
+		choices = []Choice{}
+	}
+
+	return &Response{
+		Type: ResponseAutocomplete,
+		Data: &ResponseData{Choices: choices},
+	}
+}
+
+// Modal cria a resposta que abre um modal; cada componente vai em uma linha própria.
+func Modal(customID, title string, inputs ...Component) *Response {
+	rows := make([]Component, 0, len(inputs))
+	for _, input := range inputs {
+		rows = append(rows, Component{Type: ComponentActionRow, Components: []Component{input}})
+	}
+
+	return &Response{
+		Type: ResponseModal,
+		Data: &ResponseData{CustomID: customID, Title: title, Components: rows},
+	}
+}
+
+// Deferred cria a resposta "pensando...", para comandos que vão responder depois.
+func Deferred(ephemeral bool) *Response {
+	response := &Response{Type: ResponseDeferredChannelMessage}
+	if ephemeral {
+		response.Data = &ResponseData{Flags: FlagEphemeral}
+	}
+	return response
+}
+
+// DeferredUpdate cria a resposta que adia a atualização da mensagem de um componente.
+func DeferredUpdate() *Response {
+	return &Response{Type: ResponseDeferredUpdate}
+}
+
+// IsDeferred indica se a resposta adia o conteúdo para depois (tipos 5 e 6).
+func (r *Response) IsDeferred() bool {
+	return r.Type == ResponseDeferredChannelMessage || r.Type == ResponseDeferredUpdate
+}
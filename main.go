@@ -4,37 +4,118 @@ import (
 	"bot-map/cmd"
 	"bot-map/config"
 	"bot-map/discord"
-	"fmt"
+	"bot-map/store"
+	"context"
+	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+// Tempo máximo de execução de um comando
+const commandTimeout = 30 * time.Second
+
+// Tempo máximo de uma chamada HTTP à API do Discord
+const httpTimeout = 15 * time.Second
+
 func main() {
 	configInstance := config.LoadConfig()
 
-	// Criação do mapa compartilhado de localidades
-	localidades := make(map[string]string)
+	// Armazenamento compartilhado das localidades: em disco quando STORAGE_DIR está definido
+	localidades, err := openLocationStore(configInstance)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Controle de acesso: donos do bot e cargos autorizados por guilda para cada ação,
+	// gravados junto com as localidades
+	policy, err := openRolePolicy(configInstance)
+	if err != nil {
+		log.Fatal(err)
+	}
+	access := cmd.NewAccessControl(configInstance.OwnerIDs, policy)
 
 	// Registra o comando /addlocal
-	addLocalCmd := cmd.NewAddLocalCommand(configInstance, &http.Client{}, localidades)
+	addLocalCmd := cmd.NewAddLocalCommand(localidades, access)
 	registry := cmd.NewCommandRegistry()
 	registry.RegistryCommand(addLocalCmd)
 
+	// Middlewares aplicados a todos os comandos: log estruturado, métricas, proteção contra pânico,
+	// permissões e cooldowns declarados nos comandos e tempo limite
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	metrics := cmd.NewCommandMetrics()
+	registry.Use(
+		cmd.Logging(logger),
+		cmd.Metrics(metrics),
+		cmd.Recover(logger),
+		access.Middleware(),
+		cmd.Cooldowns(cmd.NewMemoryCooldownStore()),
+		cmd.Timeout(commandTimeout),
+	)
+
 	// Registra o comando /local
-	localCmd := cmd.NewLocalCommand(configInstance, &http.Client{}, localidades)
+	localCmd := cmd.NewLocalCommand(localidades, access)
 	registry.RegistryCommand(localCmd)
 
-	// Verificar se ambos os comandos estão usando o mesmo mapa
-	fmt.Println("Mapa de localidades antes do bot iniciar:", localidades)
+	// Registra o comando /permissoes, usado pelos administradores para definir os cargos autorizados
+	registry.RegistryCommand(cmd.NewPermissionsCommand(access.Policy))
+
+	// Registra o comando /transferir, usado pelos donos do bot para copiar ou mover localidades entre servidores
+	registry.RegistryCommand(cmd.NewTransferCommand(localidades))
 
 	// Inicializa o cliente do Discord
-	discordClient := discord.GetDiscordClient(configInstance, registry)
-	discordClient.RegisterSlashCommands()
+	httpClient := &http.Client{Timeout: httpTimeout}
+	discordClient := discord.NewDiscordClient(configInstance, registry, discord.WithHTTPClient(httpClient))
+	if err := discordClient.RegisterSlashCommands(); err != nil {
+		log.Printf("failed to sync slash commands: %v", err)
+	}
+
+	// Encerra o bot de forma graciosa ao receber SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Conecta ao gateway do Discord
-	if err := discordClient.ConnectToGateway(); err != nil {
-		discordClient.HandleError(err)
+	// Conecta ao gateway com um cliente por shard e mantém o bot rodando até o desligamento ou um erro fatal
+	shardManager := discord.NewShardManager(configInstance, registry,
+		discord.WithHTTPClient(httpClient),
+		discord.WithShutdownHook(func(ctx context.Context) error {
+			// Grava o snapshot final das localidades depois que os comandos em andamento terminam
+			return localidades.Close()
+		}),
+		discord.WithShutdownHook(func(ctx context.Context) error {
+			// Registra os números acumulados dos comandos antes de sair
+			for _, stats := range metrics.Snapshot() {
+				logger.Info("command stats",
+					"command", stats.Command,
+					"calls", stats.Calls,
+					"errors", stats.Errors,
+					"avg_latency", stats.AverageLatency(),
+					"max_latency", stats.MaxLatency,
+				)
+			}
+			return nil
+		}),
+	)
+	if err := shardManager.Run(ctx); err != nil {
+		log.Fatal(err)
 	}
+}
 
-	// Mantém o bot rodando
-	select {}
+// Abre o armazenamento de localidades configurado
+func openLocationStore(config *config.Config) (store.LocationStore, error) {
+	if config.StorageDir == "" {
+		return store.NewMemoryStore(), nil
+	}
+	// Localidades gravadas antes da separação por servidor ficam com a guilda configurada
+	return store.OpenFileStore(config.StorageDir, store.GuildNamespace(config.GuildID))
+}
+
+// Abre a política de cargos configurada
+func openRolePolicy(config *config.Config) (cmd.RolePolicy, error) {
+	if config.StorageDir == "" {
+		return cmd.NewMemoryRolePolicy(), nil
+	}
+	return store.OpenFileRolePolicy(config.StorageDir)
 }
@@ -0,0 +1,420 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Arquivos mantidos no diretório do FileStore.
+const (
+	snapshotFile = "snapshot.json" // Estado completo no momento do último snapshot
+	journalFile  = "journal.log"   // Operações feitas depois do snapshot, uma por linha
+)
+
+// Quantidade padrão de operações no journal antes de um novo snapshot.
+const defaultSnapshotEvery = 100
+
+// Operações gravadas no journal.
+const (
+	opPut    = "put"
+	opDelete = "delete"
+)
+
+// Uma linha do journal.
+type journalRecord struct {
+	Op        string    `json:"op"`
+	Namespace Namespace `json:"namespace,omitempty"` // Namespace da operação (vazio em journals antigos)
+	Name      string    `json:"name,omitempty"`      // Localidade removida (delete)
+	Location  *Location `json:"location,omitempty"`  // Estado gravado (put)
+}
+
+// Localidade como aparece no snapshot, acompanhada do namespace.
+// Snapshots antigos, sem namespace, continuam sendo lidos pela mesma estrutura.
+type snapshotEntry struct {
+	Namespace Namespace `json:"namespace,omitempty"`
+	Location
+}
+
+// FileStore guarda as localidades em disco de forma resistente a quedas: cada alteração é
+// anexada a um journal e sincronizada (fsync) antes de ser aplicada em memória, e de tempos
+// em tempos o estado completo vira um snapshot gravado em arquivo temporário e renomeado.
+// Ao abrir, o snapshot é carregado e o journal é reaplicado por cima.
+type FileStore struct {
+	SnapshotEvery int         // Operações no journal que disparam um snapshot
+	Logger        *log.Logger // Logger das falhas que não impedem a gravação
+
+	mu         sync.RWMutex
+	dir        string
+	legacy     Namespace // Namespace das localidades gravadas antes da separação por guilda
+	namespaces map[Namespace]map[string]Location
+	journal    *os.File
+	pending    int   // Operações no journal desde o último snapshot
+	offset     int64 // Fim da última gravação completa do journal
+	closed     bool  // Indica que Close já foi chamado
+}
+
+// Abre (ou cria) o FileStore no diretório informado, recuperando o estado gravado.
+// Localidades gravadas antes da separação por namespace passam a pertencer a legacy.
+func OpenFileStore(dir string, legacy Namespace) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &FileStore{
+		SnapshotEvery: defaultSnapshotEvery,
+		Logger:        log.Default(),
+		dir:           dir,
+		legacy:        legacy,
+		namespaces:    make(map[Namespace]map[string]Location),
+	}
+
+	if err := s.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	journal, err := os.OpenFile(filepath.Join(dir, journalFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s.journal = journal
+
+	if err := s.replayJournal(); err != nil {
+		journal.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *FileStore) Get(ns Namespace, name string) (Location, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	location, ok := s.namespaces[ns][name]
+	if !ok {
+		return Location{}, ErrNotFound
+	}
+	return cloneLocation(location), nil
+}
+
+func (s *FileStore) Put(ns Namespace, location Location) (Location, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	location, err := nextVersion(s.namespaces[ns], location)
+	if err != nil {
+		return Location{}, err
+	}
+
+	if err := s.commit(journalRecord{Op: opPut, Namespace: ns, Location: &location}); err != nil {
+		return Location{}, err
+	}
+	return cloneLocation(location), nil
+}
+
+func (s *FileStore) Delete(ns Namespace, name string, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := checkDelete(s.namespaces[ns], name, version); err != nil {
+		return err
+	}
+	return s.commit(journalRecord{Op: opDelete, Namespace: ns, Name: name})
+}
+
+func (s *FileStore) List(ns Namespace) ([]Location, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return listLocations(s.namespaces[ns]), nil
+}
+
+func (s *FileStore) Search(ns Namespace, query string, limit int) ([]Location, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return searchLocations(s.namespaces[ns], query, limit), nil
+}
+
+// Transfer grava no journal, para cada localidade, a cópia no destino antes da remoção na origem:
+// uma queda no meio pode deixar localidades duplicadas, mas nunca perdidas.
+func (s *FileStore) Transfer(from, to Namespace, move bool) (TransferResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if from == to {
+		return TransferResult{}, nil
+	}
+
+	copies, result := planTransfer(s.namespaces[from], s.namespaces[to])
+
+	records := make([]journalRecord, 0, 2*len(copies))
+	for idx := range copies {
+		records = append(records, journalRecord{Op: opPut, Namespace: to, Location: &copies[idx]})
+		if move {
+			records = append(records, journalRecord{Op: opDelete, Namespace: from, Name: copies[idx].Name})
+		}
+	}
+
+	if err := s.commit(records...); err != nil {
+		return TransferResult{}, err
+	}
+	return result, nil
+}
+
+// Snapshot grava o estado completo e esvazia o journal.
+func (s *FileStore) Snapshot() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.snapshot()
+}
+
+// Close grava um snapshot final e fecha o journal.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	snapshotErr := s.snapshot()
+	return errors.Join(snapshotErr, s.journal.Close())
+}
+
+// Grava as operações no journal, sincroniza e só então aplica em memória.
+// Precisa ser chamada com o lock de escrita.
+func (s *FileStore) commit(records ...journalRecord) error {
+	if s.closed {
+		return errors.New("armazenamento de localidades fechado")
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	var lines []byte
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+
+	if _, err := s.journal.Write(lines); err != nil {
+		return errors.Join(fmt.Errorf("falha ao gravar o journal: %w", err), s.rollback())
+	}
+	if err := s.journal.Sync(); err != nil {
+		return errors.Join(fmt.Errorf("falha ao sincronizar o journal: %w", err), s.rollback())
+	}
+	s.offset += int64(len(lines))
+
+	for _, record := range records {
+		s.apply(record)
+	}
+	s.pending += len(records)
+
+	if s.pending >= s.SnapshotEvery {
+		// A operação já está segura no journal; uma falha aqui só adia o snapshot para a próxima
+		if err := s.snapshot(); err != nil {
+			s.Logger.Printf("falha ao gravar o snapshot das localidades: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Descarta o que uma gravação interrompida deixou no journal, voltando ao fim da última
+// gravação completa. Sem isso a próxima linha seria anexada a um pedaço de JSON e o
+// journal ficaria corrompido no meio.
+func (s *FileStore) rollback() error {
+	if err := s.journal.Truncate(s.offset); err != nil {
+		return fmt.Errorf("falha ao desfazer a gravação do journal: %w", err)
+	}
+	if _, err := s.journal.Seek(s.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("falha ao desfazer a gravação do journal: %w", err)
+	}
+	return nil
+}
+
+// Aplica uma operação do journal ao estado em memória.
+func (s *FileStore) apply(record journalRecord) {
+	ns := s.namespaceOf(record.Namespace)
+
+	switch record.Op {
+	case opPut:
+		if record.Location != nil {
+			s.namespace(ns)[record.Location.Name] = migrateLocation(ns, *record.Location)
+		}
+	case opDelete:
+		delete(s.namespaces[ns], record.Name)
+	}
+}
+
+// Retorna o mapa do namespace, criando-o se necessário.
+func (s *FileStore) namespace(ns Namespace) map[string]Location {
+	locations, ok := s.namespaces[ns]
+	if !ok {
+		locations = make(map[string]Location)
+		s.namespaces[ns] = locations
+	}
+	return locations
+}
+
+// Registros gravados antes dos namespaces pertencem ao namespace legado.
+func (s *FileStore) namespaceOf(ns Namespace) Namespace {
+	if ns == "" {
+		return s.legacy
+	}
+	return ns
+}
+
+// Grava o snapshot em um arquivo temporário, sincroniza e renomeia por cima do anterior,
+// para que uma queda no meio nunca deixe um snapshot pela metade. Depois esvazia o journal.
+// Se a queda acontecer entre a renomeação e o esvaziamento, reaplicar o journal sobre o
+// snapshot novo chega ao mesmo estado, já que cada operação grava o valor final.
+func (s *FileStore) snapshot() error {
+	var entries []snapshotEntry
+	for ns, locations := range s.namespaces {
+		for _, location := range listLocations(locations) {
+			entries = append(entries, snapshotEntry{Namespace: ns, Location: location})
+		}
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		if entries[a].Namespace != entries[b].Namespace {
+			return entries[a].Namespace < entries[b].Namespace
+		}
+		return entries[a].Name < entries[b].Name
+	})
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(s.dir, snapshotFile)
+	if err := writeFileAtomic(path, data); err != nil {
+		return err
+	}
+
+	if err := s.journal.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := s.journal.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := s.journal.Sync(); err != nil {
+		return err
+	}
+
+	s.pending = 0
+	s.offset = 0
+	return nil
+}
+
+// Carrega o último snapshot, se existir.
+func (s *FileStore) loadSnapshot() error {
+	data, err := os.ReadFile(filepath.Join(s.dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var entries []snapshotEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("snapshot de localidades corrompido: %w", err)
+	}
+
+	for _, entry := range entries {
+		ns := s.namespaceOf(entry.Namespace)
+		s.namespace(ns)[entry.Name] = migrateLocation(ns, entry.Location)
+	}
+	return nil
+}
+
+// Reaplica o journal sobre o snapshot. Uma linha incompleta no final (queda durante a escrita)
+// é descartada e o journal é cortado nesse ponto, para que as próximas gravações fiquem íntegras.
+// Uma linha inválida seguida de outras não é consequência de uma queda: nesse caso a abertura
+// falha, em vez de descartar as operações válidas que vêm depois.
+func (s *FileStore) replayJournal() error {
+	reader := bufio.NewReader(s.journal)
+
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// Sobrou uma linha sem '\n': a escrita foi interrompida
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var record journalRecord
+		if err := json.Unmarshal(bytes.TrimSpace(line), &record); err != nil {
+			if _, peekErr := reader.Peek(1); !errors.Is(peekErr, io.EOF) {
+				return fmt.Errorf("journal de localidades corrompido na posição %d: %w", offset, err)
+			}
+			break
+		}
+
+		s.apply(record)
+		s.pending++
+		offset += int64(len(line))
+	}
+
+	if err := s.journal.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := s.journal.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	s.offset = offset
+	return s.journal.Sync()
+}
+
+// Grava o arquivo de forma atômica: arquivo temporário, fsync, rename e fsync do diretório.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Não faz nada depois do rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// Sincroniza o diretório para que a renomeação sobreviva a uma queda
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
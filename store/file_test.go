@@ -0,0 +1,246 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testNamespace = GuildNamespace("1")
+
+// Abre o FileStore no diretório e fecha ao fim do teste (Close repetido não faz nada).
+func openTestStore(t *testing.T, dir string) *FileStore {
+	t.Helper()
+
+	s, err := OpenFileStore(dir, testNamespace)
+	if err != nil {
+		t.Fatalf("OpenFileStore: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// Simula uma queda: fecha o journal sem gravar o snapshot final.
+func crash(t *testing.T, s *FileStore) {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if err := s.journal.Close(); err != nil {
+		t.Fatalf("fechar journal: %v", err)
+	}
+}
+
+func mustPut(t *testing.T, s LocationStore, ns Namespace, location Location) Location {
+	t.Helper()
+
+	saved, err := s.Put(ns, location)
+	if err != nil {
+		t.Fatalf("Put(%s): %v", location.Name, err)
+	}
+	return saved
+}
+
+func mustGet(t *testing.T, s LocationStore, ns Namespace, name string) Location {
+	t.Helper()
+
+	location, err := s.Get(ns, name)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", name, err)
+	}
+	return location
+}
+
+func TestFileStoreReopenAfterPutAndDelete(t *testing.T) {
+	dir := t.TempDir()
+
+	s := openTestStore(t, dir)
+	vila := mustPut(t, s, testNamespace, Location{Name: "Vila", Description: "Vila inicial", UpdatedBy: "10"})
+	mustPut(t, s, testNamespace, Location{Name: "Porto", Description: "Porto do sul"})
+	vila.Description = "Vila reformada"
+	vila = mustPut(t, s, testNamespace, vila)
+	if err := s.Delete(testNamespace, "Porto", 1); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	crash(t, s)
+
+	reopened := openTestStore(t, dir)
+	got := mustGet(t, reopened, testNamespace, "Vila")
+	if got.Description != "Vila reformada" || got.Version != 2 || got.ID != vila.ID || got.AuthorID != "10" {
+		t.Errorf("Vila depois de reabrir = %+v, esperado %+v", got, vila)
+	}
+	if _, err := reopened.Get(testNamespace, "Porto"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Porto depois de reabrir: erro = %v, esperado ErrNotFound", err)
+	}
+
+	// O mesmo estado vale depois de um Close com snapshot
+	if err := reopened.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	again := openTestStore(t, dir)
+	if got := mustGet(t, again, testNamespace, "Vila"); got.Version != 2 {
+		t.Errorf("versão depois do snapshot = %d, esperado 2", got.Version)
+	}
+}
+
+func TestFileStoreDiscardsTornLastLine(t *testing.T) {
+	dir := t.TempDir()
+
+	s := openTestStore(t, dir)
+	mustPut(t, s, testNamespace, Location{Name: "Vila", Description: "Vila inicial"})
+	crash(t, s)
+
+	journal := filepath.Join(dir, journalFile)
+	valid, err := os.ReadFile(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	torn := append(append([]byte(nil), valid...), `{"op":"put","namespace":"guild:1","location":{"name":"Por`...)
+	if err := os.WriteFile(journal, torn, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := openTestStore(t, dir)
+	mustGet(t, reopened, testNamespace, "Vila")
+	if _, err := reopened.Get(testNamespace, "Porto"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("localidade da linha cortada: erro = %v, esperado ErrNotFound", err)
+	}
+
+	// A linha cortada sai do journal e as próximas gravações continuam legíveis
+	mustPut(t, reopened, testNamespace, Location{Name: "Porto", Description: "Porto do sul"})
+	crash(t, reopened)
+
+	again := openTestStore(t, dir)
+	mustGet(t, again, testNamespace, "Vila")
+	mustGet(t, again, testNamespace, "Porto")
+}
+
+func TestFileStoreRejectsCorruptLineInTheMiddle(t *testing.T) {
+	dir := t.TempDir()
+
+	s := openTestStore(t, dir)
+	mustPut(t, s, testNamespace, Location{Name: "Vila", Description: "Vila inicial"})
+	crash(t, s)
+
+	journal := filepath.Join(dir, journalFile)
+	valid, err := os.ReadFile(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupt := append([]byte("lixo\n"), valid...)
+	if err := os.WriteFile(journal, corrupt, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenFileStore(dir, testNamespace); err == nil {
+		t.Fatal("OpenFileStore aceitou um journal corrompido no meio")
+	}
+
+	// O journal não pode ter sido cortado: as operações válidas continuam lá
+	after, err := os.ReadFile(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(corrupt) {
+		t.Errorf("journal alterado ao recusar a abertura: %q", after)
+	}
+}
+
+func TestFileStoreCrashBetweenSnapshotAndTruncate(t *testing.T) {
+	dir := t.TempDir()
+
+	s := openTestStore(t, dir)
+	mustPut(t, s, testNamespace, Location{Name: "Vila", Description: "Vila inicial"})
+	porto := mustPut(t, s, testNamespace, Location{Name: "Porto", Description: "Porto do sul"})
+	porto.Description = "Porto reformado"
+	mustPut(t, s, testNamespace, porto)
+	if err := s.Delete(testNamespace, "Vila", AnyVersion); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	journal := filepath.Join(dir, journalFile)
+	beforeSnapshot, err := os.ReadFile(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Snapshot(); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	crash(t, s)
+
+	// A queda aconteceu depois do rename do snapshot e antes de esvaziar o journal
+	if err := os.WriteFile(journal, beforeSnapshot, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := openTestStore(t, dir)
+	if got := mustGet(t, reopened, testNamespace, "Porto"); got.Description != "Porto reformado" || got.Version != 2 {
+		t.Errorf("Porto depois de reaplicar o journal = %+v", got)
+	}
+	if _, err := reopened.Get(testNamespace, "Vila"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Vila depois de reaplicar o journal: erro = %v, esperado ErrNotFound", err)
+	}
+}
+
+func TestFileStoreMigratesLegacyData(t *testing.T) {
+	dir := t.TempDir()
+
+	// Dados anteriores aos namespaces e ao modelo atual: só nome, descrição e autor da alteração
+	snapshot := `[{"name":"Vila","description":"Vila inicial","updated_by":"10"}]`
+	journal := `{"op":"put","location":{"name":"Porto","description":"Porto do sul"}}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, snapshotFile), []byte(snapshot), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, journalFile), []byte(journal), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := openTestStore(t, dir)
+	vila := mustGet(t, s, testNamespace, "Vila")
+	if vila.Version != 1 || vila.ID == "" || vila.AuthorID != "10" {
+		t.Errorf("Vila migrada = %+v", vila)
+	}
+	porto := mustGet(t, s, testNamespace, "Porto")
+	if porto.Version != 1 || porto.ID == "" {
+		t.Errorf("Porto migrado = %+v", porto)
+	}
+
+	// O ID derivado é o mesmo depois de reabrir, mesmo sem snapshot novo
+	crash(t, s)
+	reopened := openTestStore(t, dir)
+	if got := mustGet(t, reopened, testNamespace, "Vila"); got.ID != vila.ID {
+		t.Errorf("ID da Vila mudou ao reabrir: %s, antes %s", got.ID, vila.ID)
+	}
+
+	// A versão migrada vale para o compare-and-swap
+	vila.Description = "Vila reformada"
+	if _, err := reopened.Put(testNamespace, vila); err != nil {
+		t.Errorf("Put com a versão migrada: %v", err)
+	}
+}
+
+func TestFileStoreRollbackDiscardsPartialWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	s := openTestStore(t, dir)
+	mustPut(t, s, testNamespace, Location{Name: "Vila", Description: "Vila inicial"})
+
+	// Parte de uma linha chegou ao journal antes de a gravação falhar
+	s.mu.Lock()
+	if _, err := s.journal.Write([]byte(`{"op":"put","loc`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.rollback(); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	s.mu.Unlock()
+
+	mustPut(t, s, testNamespace, Location{Name: "Porto", Description: "Porto do sul"})
+	crash(t, s)
+
+	reopened := openTestStore(t, dir)
+	mustGet(t, reopened, testNamespace, "Vila")
+	mustGet(t, reopened, testNamespace, "Porto")
+}
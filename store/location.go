@@ -0,0 +1,198 @@
+package store
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Erro devolvido quando a localidade não existe.
+var ErrNotFound = errors.New("localidade não encontrada")
+
+// Erro devolvido quando a localidade mudou desde a versão lida (veja ConflictError).
+var ErrConflict = errors.New("localidade modificada por outra pessoa")
+
+// Versão esperada que ignora a verificação em Put e Delete.
+const AnyVersion int64 = -1
+
+// Location é uma localidade cadastrada.
+type Location struct {
+	ID          string       `json:"id"`                    // Identificador estável, gerado na criação
+	Name        string       `json:"name"`                  // Nome exibido, também usado como chave dentro do namespace
+	Description string       `json:"description"`           // Descrição exibida no /local
+	AuthorID    string       `json:"author_id,omitempty"`   // ID do usuário que criou a localidade
+	CreatedAt   time.Time    `json:"created_at,omitzero"`   // Momento da criação (zero em dados antigos)
+	UpdatedAt   time.Time    `json:"updated_at,omitzero"`   // Momento da última alteração
+	Category    string       `json:"category,omitempty"`    // Categoria (ex.: "cidade", "masmorra")
+	Tags        []string     `json:"tags,omitempty"`        // Tags da localidade
+	Coordinates *Coordinates `json:"coordinates,omitempty"` // Coordenadas, quando informadas
+	ImageURL    string       `json:"image_url,omitempty"`   // Imagem exibida no /local
+	Link        string       `json:"link,omitempty"`        // Link externo com mais informações
+	Version     int64        `json:"version"`               // Incrementada a cada alteração; zero antes de ser gravada
+	UpdatedBy   string       `json:"updated_by,omitempty"`  // ID do usuário que fez a última alteração
+}
+
+// Coordinates são a latitude e a longitude de uma localidade.
+type Coordinates struct {
+	Latitude  float64 `json:"lat"`
+	Longitude float64 `json:"lng"`
+}
+
+// ConflictError indica que a localidade foi alterada (ou removida) depois de lida.
+type ConflictError struct {
+	Name    string    // Localidade em conflito
+	Current *Location // Estado atual; nil se a localidade foi removida
+}
+
+func (e *ConflictError) Error() string {
+	if e.Current == nil {
+		return fmt.Sprintf("localidade %s removida enquanto era editada", e.Name)
+	}
+	return fmt.Sprintf("localidade %s modificada por %s (versão %d)", e.Name, e.Current.UpdatedBy, e.Current.Version)
+}
+
+// Is permite usar errors.Is(err, ErrConflict).
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// LocationStore guarda as localidades, separadas por namespace (guilda ou usuário).
+// As implementações podem ser usadas por vários comandos ao mesmo tempo.
+type LocationStore interface {
+	// Get retorna a localidade pelo nome ou ErrNotFound.
+	Get(ns Namespace, name string) (Location, error)
+	// Put grava a localidade se a versão guardada for igual a location.Version (compare-and-swap):
+	// zero cria uma localidade nova e AnyVersion grava sem verificar. Retorna a localidade gravada,
+	// com a nova versão, ou um ConflictError.
+	Put(ns Namespace, location Location) (Location, error)
+	// Delete remove a localidade se a versão guardada for igual a version (ou com AnyVersion).
+	// Devolve ErrNotFound se ela não existe ou um ConflictError se a versão mudou.
+	Delete(ns Namespace, name string, version int64) error
+	// List retorna todas as localidades do namespace em ordem de nome.
+	List(ns Namespace) ([]Location, error)
+	// Search retorna até limit localidades do namespace cujo nome contém query (sem diferenciar
+	// maiúsculas), com as que começam por query primeiro.
+	Search(ns Namespace, query string, limit int) ([]Location, error)
+	// Transfer copia as localidades de um namespace para outro, sem sobrescrever as que já existem
+	// no destino; com move, as copiadas são removidas da origem.
+	Transfer(from, to Namespace, move bool) (TransferResult, error)
+	// Close grava o que estiver pendente e libera os recursos do armazenamento.
+	Close() error
+}
+
+// Confere a versão esperada de location contra a guardada e retorna a localidade com a próxima versão.
+func nextVersion(locations map[string]Location, location Location) (Location, error) {
+	current, exists := locations[location.Name]
+
+	if location.Version != AnyVersion {
+		switch {
+		case !exists && location.Version != 0:
+			return Location{}, &ConflictError{Name: location.Name}
+		case exists && current.Version != location.Version:
+			conflict := cloneLocation(current)
+			return Location{}, &ConflictError{Name: location.Name, Current: &conflict}
+		}
+	}
+
+	location = cloneLocation(location)
+	location.Version = current.Version + 1
+
+	// ID, autor e data de criação são definidos na criação e preservados nas alterações
+	now := time.Now().UTC()
+	if exists {
+		location.ID = current.ID
+		location.AuthorID = current.AuthorID
+		location.CreatedAt = current.CreatedAt
+	} else {
+		location.ID = newLocationID()
+		location.AuthorID = location.UpdatedBy
+		location.CreatedAt = now
+	}
+	location.UpdatedAt = now
+
+	return location, nil
+}
+
+// Gera um ID aleatório para uma localidade nova.
+func newLocationID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// Completa localidades gravadas antes do modelo atual, que tinham apenas nome e descrição:
+// recebem a primeira versão e um ID derivado do namespace e do nome, estável entre reinícios
+// mesmo antes do próximo snapshot.
+func migrateLocation(ns Namespace, location Location) Location {
+	if location.Version == 0 {
+		location.Version = 1
+	}
+	if location.ID == "" {
+		sum := sha256.Sum256([]byte(string(ns) + "/" + location.Name))
+		location.ID = hex.EncodeToString(sum[:8])
+	}
+	if location.AuthorID == "" {
+		location.AuthorID = location.UpdatedBy
+	}
+	return location
+}
+
+// Confere a versão esperada antes de remover a localidade.
+func checkDelete(locations map[string]Location, name string, version int64) error {
+	current, exists := locations[name]
+	if !exists {
+		return ErrNotFound
+	}
+	if version != AnyVersion && current.Version != version {
+		conflict := cloneLocation(current)
+		return &ConflictError{Name: name, Current: &conflict}
+	}
+	return nil
+}
+
+// Copia a localidade para que quem chamou não altere os dados guardados.
+func cloneLocation(location Location) Location {
+	location.Tags = append([]string(nil), location.Tags...)
+	if location.Coordinates != nil {
+		coordinates := *location.Coordinates
+		location.Coordinates = &coordinates
+	}
+	return location
+}
+
+// Lista as localidades do mapa em ordem de nome.
+func listLocations(locations map[string]Location) []Location {
+	list := make([]Location, 0, len(locations))
+	for _, location := range locations {
+		list = append(list, cloneLocation(location))
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].Name < list[b].Name })
+	return list
+}
+
+// Busca as localidades do mapa pelo nome.
+func searchLocations(locations map[string]Location, query string, limit int) []Location {
+	query = strings.ToLower(query)
+
+	var prefixed, contained []Location
+	for _, location := range listLocations(locations) {
+		name := strings.ToLower(location.Name)
+		switch {
+		case strings.HasPrefix(name, query):
+			prefixed = append(prefixed, location)
+		case strings.Contains(name, query):
+			contained = append(contained, location)
+		}
+	}
+
+	results := append(prefixed, contained...)
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
@@ -0,0 +1,99 @@
+package store
+
+import "sync"
+
+// MemoryStore guarda as localidades apenas em memória; os dados se perdem ao reiniciar.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	namespaces map[Namespace]map[string]Location
+}
+
+// Cria um MemoryStore vazio.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{namespaces: make(map[Namespace]map[string]Location)}
+}
+
+func (s *MemoryStore) Get(ns Namespace, name string) (Location, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	location, ok := s.namespaces[ns][name]
+	if !ok {
+		return Location{}, ErrNotFound
+	}
+	return cloneLocation(location), nil
+}
+
+func (s *MemoryStore) Put(ns Namespace, location Location) (Location, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	locations := s.namespace(ns)
+	location, err := nextVersion(locations, location)
+	if err != nil {
+		return Location{}, err
+	}
+
+	locations[location.Name] = location
+	return cloneLocation(location), nil
+}
+
+func (s *MemoryStore) Delete(ns Namespace, name string, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := checkDelete(s.namespaces[ns], name, version); err != nil {
+		return err
+	}
+	delete(s.namespaces[ns], name)
+	return nil
+}
+
+func (s *MemoryStore) List(ns Namespace) ([]Location, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return listLocations(s.namespaces[ns]), nil
+}
+
+func (s *MemoryStore) Search(ns Namespace, query string, limit int) ([]Location, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return searchLocations(s.namespaces[ns], query, limit), nil
+}
+
+func (s *MemoryStore) Transfer(from, to Namespace, move bool) (TransferResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if from == to {
+		return TransferResult{}, nil
+	}
+
+	copies, result := planTransfer(s.namespaces[from], s.namespaces[to])
+
+	target := s.namespace(to)
+	for _, location := range copies {
+		target[location.Name] = location
+		if move {
+			delete(s.namespaces[from], location.Name)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// Retorna o mapa do namespace, criando-o se necessário. Precisa ser chamada com o lock de escrita.
+func (s *MemoryStore) namespace(ns Namespace) map[string]Location {
+	locations, ok := s.namespaces[ns]
+	if !ok {
+		locations = make(map[string]Location)
+		s.namespaces[ns] = locations
+	}
+	return locations
+}
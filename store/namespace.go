@@ -0,0 +1,69 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Namespace separa as localidades de cada guilda; nas DMs cada usuário tem o próprio.
+type Namespace string
+
+// GuildNamespace retorna o namespace das localidades de uma guilda.
+func GuildNamespace(guildID string) Namespace {
+	return Namespace("guild:" + guildID)
+}
+
+// UserNamespace retorna o namespace pessoal de um usuário, usado nas DMs.
+func UserNamespace(userID string) Namespace {
+	return Namespace("user:" + userID)
+}
+
+// ParseNamespace aceita "guild:<id>", "user:<id>" ou apenas o ID de uma guilda.
+func ParseNamespace(value string) (Namespace, error) {
+	value = strings.TrimSpace(value)
+
+	kind, id, found := strings.Cut(value, ":")
+	if !found {
+		kind, id = "guild", value
+	}
+
+	if id == "" || strings.Trim(id, "0123456789") != "" {
+		return "", fmt.Errorf("namespace inválido: %q", value)
+	}
+
+	switch kind {
+	case "guild":
+		return GuildNamespace(id), nil
+	case "user":
+		return UserNamespace(id), nil
+	default:
+		return "", fmt.Errorf("namespace inválido: %q", value)
+	}
+}
+
+// TransferResult descreve o resultado de uma cópia ou movimentação entre namespaces.
+type TransferResult struct {
+	Copied  []string // Localidades copiadas para o destino
+	Skipped []string // Localidades que já existiam no destino e foram mantidas como estavam
+}
+
+// Escolhe o que será copiado: localidades já existentes no destino não são sobrescritas.
+// As cópias começam uma nova história de versões no destino, com um ID próprio; autor e datas são mantidos.
+func planTransfer(source, target map[string]Location) ([]Location, TransferResult) {
+	var copies []Location
+	var result TransferResult
+
+	for _, location := range listLocations(source) {
+		if _, exists := target[location.Name]; exists {
+			result.Skipped = append(result.Skipped, location.Name)
+			continue
+		}
+
+		location.Version = 1
+		location.ID = newLocationID()
+		copies = append(copies, location)
+		result.Copied = append(result.Copied, location.Name)
+	}
+
+	return copies, result
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// Arquivo com os cargos autorizados de cada guilda, no mesmo diretório das localidades.
+const rolesFile = "roles.json"
+
+// FileRolePolicy guarda em disco, por guilda, os cargos autorizados para cada ação sobre as
+// localidades (usada como cmd.RolePolicy). Cada alteração regrava o arquivo inteiro de forma
+// atômica antes de valer em memória, então uma queda nunca deixa a configuração pela metade.
+type FileRolePolicy struct {
+	mu    sync.RWMutex
+	path  string
+	roles map[string]map[string][]string // guilda -> ação -> cargos
+}
+
+// Abre (ou cria) a política de cargos gravada no diretório informado.
+func OpenFileRolePolicy(dir string) (*FileRolePolicy, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	p := &FileRolePolicy{
+		path:  filepath.Join(dir, rolesFile),
+		roles: make(map[string]map[string][]string),
+	}
+
+	data, err := os.ReadFile(p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &p.roles); err != nil {
+		return nil, fmt.Errorf("cargos autorizados corrompidos: %w", err)
+	}
+	return p, nil
+}
+
+// Roles retorna uma cópia dos cargos autorizados para a ação na guilda.
+func (p *FileRolePolicy) Roles(guildID, action string) []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return append([]string(nil), p.roles[guildID][action]...)
+}
+
+// SetRoles substitui os cargos autorizados para a ação na guilda; uma lista vazia libera a ação.
+// A alteração só vale em memória depois de gravada no disco.
+func (p *FileRolePolicy) SetRoles(guildID, action string, roles []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.setRoles(guildID, action, roles)
+}
+
+// AddRole autoriza o cargo para a ação na guilda; retorna false se ele já estava autorizado.
+func (p *FileRolePolicy) AddRole(guildID, action, roleID string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	roles := p.roles[guildID][action]
+	if slices.Contains(roles, roleID) {
+		return false, nil
+	}
+	if err := p.setRoles(guildID, action, append(slices.Clip(roles), roleID)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveRole retira a autorização do cargo para a ação na guilda e retorna os cargos que continuam autorizados.
+func (p *FileRolePolicy) RemoveRole(guildID, action, roleID string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	remaining := slices.DeleteFunc(slices.Clone(p.roles[guildID][action]), func(existing string) bool {
+		return existing == roleID
+	})
+	if err := p.setRoles(guildID, action, remaining); err != nil {
+		return nil, err
+	}
+	return remaining, nil
+}
+
+// Grava a nova lista de cargos e só então a aplica em memória; precisa ser chamada com p.mu.
+func (p *FileRolePolicy) setRoles(guildID, action string, roles []string) error {
+	guild := make(map[string][]string, len(p.roles[guildID])+1)
+	for existing, existingRoles := range p.roles[guildID] {
+		guild[existing] = existingRoles
+	}
+	if len(roles) == 0 {
+		delete(guild, action)
+	} else {
+		guild[action] = append([]string(nil), roles...)
+	}
+
+	next := make(map[string]map[string][]string, len(p.roles)+1)
+	for existing, existingGuild := range p.roles {
+		next[existing] = existingGuild
+	}
+	if len(guild) == 0 {
+		delete(next, guildID)
+	} else {
+		next[guildID] = guild
+	}
+
+	data, err := json.Marshal(next)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(p.path, data); err != nil {
+		return fmt.Errorf("falha ao gravar os cargos autorizados: %w", err)
+	}
+
+	p.roles = next
+	return nil
+}
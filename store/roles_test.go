@@ -0,0 +1,87 @@
+package store
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestFileRolePolicyPersistsAcrossReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	p, err := OpenFileRolePolicy(dir)
+	if err != nil {
+		t.Fatalf("OpenFileRolePolicy: %v", err)
+	}
+	if err := p.SetRoles("1", "criar", []string{"10", "11"}); err != nil {
+		t.Fatalf("SetRoles: %v", err)
+	}
+	if err := p.SetRoles("1", "remover", []string{"12"}); err != nil {
+		t.Fatalf("SetRoles: %v", err)
+	}
+	if err := p.SetRoles("1", "remover", nil); err != nil {
+		t.Fatalf("SetRoles: %v", err)
+	}
+
+	reopened, err := OpenFileRolePolicy(dir)
+	if err != nil {
+		t.Fatalf("OpenFileRolePolicy: %v", err)
+	}
+	if got := reopened.Roles("1", "criar"); !reflect.DeepEqual(got, []string{"10", "11"}) {
+		t.Errorf("Roles(criar) = %v, esperado [10 11]", got)
+	}
+	if got := reopened.Roles("1", "remover"); len(got) != 0 {
+		t.Errorf("Roles(remover) = %v, esperado vazio", got)
+	}
+	if got := reopened.Roles("2", "criar"); len(got) != 0 {
+		t.Errorf("Roles de outra guilda = %v, esperado vazio", got)
+	}
+}
+
+func TestFileRolePolicyConcurrentAddAndRemove(t *testing.T) {
+	dir := t.TempDir()
+
+	p, err := OpenFileRolePolicy(dir)
+	if err != nil {
+		t.Fatalf("OpenFileRolePolicy: %v", err)
+	}
+	if err := p.SetRoles("1", "criar", []string{"remover-me"}); err != nil {
+		t.Fatalf("SetRoles: %v", err)
+	}
+
+	// Administradores alterando cargos ao mesmo tempo não perdem a alteração um do outro
+	var wg sync.WaitGroup
+	for role := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.AddRole("1", "criar", strconv.Itoa(role)); err != nil {
+				t.Errorf("AddRole: %v", err)
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if _, err := p.RemoveRole("1", "criar", "remover-me"); err != nil {
+			t.Errorf("RemoveRole: %v", err)
+		}
+	}()
+	wg.Wait()
+
+	if added, err := p.AddRole("1", "criar", "3"); err != nil || added {
+		t.Errorf("AddRole de um cargo já autorizado = %t, %v", added, err)
+	}
+
+	reopened, err := OpenFileRolePolicy(dir)
+	if err != nil {
+		t.Fatalf("OpenFileRolePolicy: %v", err)
+	}
+	got := reopened.Roles("1", "criar")
+	slices.Sort(got)
+	if want := []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Roles(criar) = %v, esperado %v", got, want)
+	}
+}